@@ -0,0 +1,164 @@
+package namebase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidAddress is returned, wrapped with the reason,
+// for addresses rejected before a withdrawal is sent
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Handshake networks, named by the human readable part of their addresses
+const (
+	HNSMainnet = "hs"
+	HNSTestnet = "ts"
+	HNSRegtest = "rs"
+)
+
+// HNSAddress is a decoded Handshake address
+type HNSAddress struct {
+	Network string
+	Version int
+	Program []byte
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// ParseHNSAddress decodes a bech32 Handshake address of any network,
+// verifying its checksum, witness version and program length.
+// Version 31 is rejected, it marks unspendable nulldata outputs
+func ParseHNSAddress(address string) (*HNSAddress, error) {
+	if len(address) < 8 || len(address) > 90 {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidAddress, len(address))
+	}
+
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return nil, fmt.Errorf("%w: mixed case", ErrInvalidAddress)
+	}
+	address = strings.ToLower(address)
+
+	sep := strings.LastIndexByte(address, '1')
+	if sep < 1 || sep+7 > len(address) {
+		return nil, fmt.Errorf("%w: missing separator", ErrInvalidAddress)
+	}
+
+	hrp := address[:sep]
+	switch hrp {
+	case HNSMainnet, HNSTestnet, HNSRegtest:
+	default:
+		return nil, fmt.Errorf("%w: unknown network %q", ErrInvalidAddress, hrp)
+	}
+
+	data := make([]byte, 0, len(address)-sep-1)
+	for _, c := range address[sep+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: invalid character %q", ErrInvalidAddress, c)
+		}
+		data = append(data, byte(i))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != 1 {
+		return nil, fmt.Errorf("%w: bad checksum", ErrInvalidAddress)
+	}
+	data = data[:len(data)-6]
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: missing witness version", ErrInvalidAddress)
+	}
+
+	version := int(data[0])
+	if version > 30 {
+		return nil, fmt.Errorf("%w: witness version %d", ErrInvalidAddress, version)
+	}
+
+	program, ok := convertBits(data[1:], 5, 8)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidAddress)
+	}
+
+	if len(program) < 2 || len(program) > 40 ||
+		version == 0 && len(program) != 20 && len(program) != 32 {
+		return nil, fmt.Errorf("%w: witness program of %d bytes for version %d",
+			ErrInvalidAddress, len(program), version)
+	}
+
+	return &HNSAddress{Network: hrp, Version: version, Program: program}, nil
+}
+
+// ValidateAddress checks address is a valid destination of asset on the
+// network the exchange runs on. Only HNS addresses can be checked,
+// those of other assets are accepted as they are
+func ValidateAddress(asset Currency, address string) error {
+	if NewCurrency(string(asset)) != "HNS" {
+		if address == "" {
+			return fmt.Errorf("%w: empty", ErrInvalidAddress)
+		}
+		return nil
+	}
+
+	addr, err := ParseHNSAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if addr.Network != HNSMainnet {
+		return fmt.Errorf("%w: %s is not a mainnet address", ErrInvalidAddress, address)
+	}
+
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+
+	return result
+}
+
+// convertBits regroups 5 bit groups into bytes, failing on non-zero padding
+func convertBits(data []byte, from, to uint) ([]byte, bool) {
+	var acc, bits uint
+	var result []byte
+	maxv := uint(1)<<to - 1
+
+	for _, v := range data {
+		acc = acc<<from | uint(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			result = append(result, byte(acc>>bits&maxv))
+		}
+	}
+
+	if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, false
+	}
+
+	return result, true
+}
@@ -0,0 +1,92 @@
+package namebase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseHNSAddress(t *testing.T) {
+	valid := map[string]string{
+		testAddress: HNSMainnet,
+		"HS1QQYPQXPQ9QCRSSZG2PVXQ6RS0ZQG3YYC50GG5VW": HNSMainnet,
+		"ts1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5m5dqvf": HNSTestnet,
+		// 32 bytes program
+		"hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5z5tpwxqergd3c8g7rusq7x2k9t": HNSMainnet,
+	}
+	for addr, network := range valid {
+		if a, err := ParseHNSAddress(addr); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		} else if a.Network != network || a.Version != 0 || a.Program[0] != 1 {
+			t.Errorf("%s: unexpected address %+v", addr, a)
+		}
+	}
+
+	invalid := []string{
+		"",
+		// checksum, the last character is changed
+		"hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vq",
+		"Hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw",
+		"bc1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw",
+		// b is not in the bech32 charset
+		"hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vb",
+		"hsqqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw",
+		// version 0 with a 16 bytes program
+		"hs1qqypqxpq9qcrsszg2pvxq6rs0zqz0qfnw",
+		// version 31 is nulldata
+		"hs1lqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5f2mj6g",
+	}
+	for _, addr := range invalid {
+		if _, err := ParseHNSAddress(addr); !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("%s: expected invalid address, got %v", addr, err)
+		}
+	}
+}
+
+func TestWithdrawValidation(t *testing.T) {
+	before := len(srv.Requests())
+
+	if _, err := nb.Withdraw("HNS", decimal.New(10, 0),
+		"ts1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5m5dqvf", ""); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("testnet address is accepted: %v", err)
+	}
+
+	// HNS has 6 decimals
+	if _, err := nb.Withdraw("HNS", decimal.RequireFromString("1.0000001"), testAddress, ""); err == nil {
+		t.Error("amount precision is not checked")
+	}
+
+	if _, err := nb.Withdraw("HNS", decimal.Zero, testAddress, ""); err == nil {
+		t.Error("zero amount is accepted")
+	}
+
+	if len(srv.Requests()) != before {
+		t.Error("invalid withdrawal has been sent")
+	}
+}
+
+func TestAssetPrecision(t *testing.T) {
+	client := &Namebase{
+		symbolInfo: map[CurrencyPair]symbolInfo{
+			NewCurrencyPair("hns", "btc"):  {BasePrecision: 6, QuotePrecision: 8},
+			NewCurrencyPair("eth", "hns"):  {BasePrecision: 8, QuotePrecision: 4},
+			NewCurrencyPair("hns", "usdt"): {BasePrecision: 5, QuotePrecision: 2},
+		},
+	}
+
+	// map order must not matter
+	for i := 0; i < 20; i++ {
+		if prec, ok := client.assetPrecision("hns"); !ok || prec != 4 {
+			t.Fatalf("unexpected HNS precision: %d, %v", prec, ok)
+		}
+	}
+
+	if prec, ok := client.assetPrecision("BTC"); !ok || prec != 8 {
+		t.Errorf("unexpected BTC precision: %d, %v", prec, ok)
+	}
+
+	if _, ok := client.assetPrecision("DOGE"); ok {
+		t.Error("unknown asset has a precision")
+	}
+}
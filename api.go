@@ -0,0 +1,59 @@
+package namebase
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarketData queries public market data
+type MarketData interface {
+	GetDepth(pair CurrencyPair, size int) (*Depth, error)
+	GetPrice(pair CurrencyPair) (decimal.Decimal, error)
+	GetKlines(pair CurrencyPair, interval KlineInterval, limit int) ([]Kline, error)
+	GetKlinesRange(pair CurrencyPair, interval KlineInterval, start, end time.Time) ([]Kline, error)
+}
+
+// Trading places and manages orders of an account
+type Trading interface {
+	LimitBuy(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error)
+	LimitSell(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error)
+	MarketBuy(amount decimal.Decimal, pair CurrencyPair) (*Order, error)
+	MarketSell(amount decimal.Decimal, pair CurrencyPair) (*Order, error)
+	CancelOrder(orderID int, pair CurrencyPair) (bool, error)
+	GetOrder(orderID int, pair CurrencyPair) (*Order, error)
+	OpenOrders(pair CurrencyPair) ([]Order, error)
+	GetAccount() (*Account, error)
+}
+
+// Wallet moves funds in and out of the exchange
+type Wallet interface {
+	DepositAddr(symbol Currency) (*DepositAddress, error)
+	NewDepositAddr(symbol Currency) (*DepositAddress, error)
+	Withdraw(symbol Currency, amount decimal.Decimal, address, memo string) (*Withdrawal, error)
+	GetWithdrawal(id string) (*Withdrawal, error)
+	WithdrawalHistory(asset Currency, from, to time.Time) ([]Withdrawal, error)
+	DepositHistory(asset Currency, from, to time.Time) ([]Deposit, error)
+}
+
+// Streaming subscribes real time market data
+type Streaming interface {
+	SubDepth(pair CurrencyPair) (chan Depth, error)
+	SubTrades(pair CurrencyPair) (chan Trade, error)
+}
+
+// Client is everything the exchange offers, strategies should rather
+// accept the smaller interfaces they need, so that they can be wired
+// to the live client, a PaperTrader or a test double
+type Client interface {
+	MarketData
+	Trading
+	Wallet
+	Streaming
+}
+
+var (
+	_ Client  = (*Namebase)(nil)
+	_ Trading = (*PaperTrader)(nil)
+	_ Trading = (*Backtest)(nil)
+)
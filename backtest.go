@@ -0,0 +1,302 @@
+package namebase
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BacktestConfig configures a Backtest
+type BacktestConfig struct {
+	Pair     CurrencyPair
+	Balances map[Currency]decimal.Decimal
+	// MakerFee and TakerFee are in basis points, as in Account.MakerFee
+	// and Account.TakerFee
+	MakerFee int
+	TakerFee int
+	// Latency delays orders before they can be matched
+	Latency time.Duration
+}
+
+// MarketEvent is a kline or a trade of the historical data,
+// Time is the close time of klines and the creation time of trades
+type MarketEvent struct {
+	Time  int64
+	Kline *Kline
+	Trade *Trade
+}
+
+// Strategy is called with every market event of a backtest,
+// after the orders have been matched against it
+type Strategy func(bt *Backtest, ev MarketEvent)
+
+// EquityPoint is the account value in quote currency at Time
+type EquityPoint struct {
+	Time   int64
+	Equity decimal.Decimal
+}
+
+// BacktestReport is the outcome of a backtest
+type BacktestReport struct {
+	Equity      []EquityPoint
+	StartEquity decimal.Decimal
+	EndEquity   decimal.Decimal
+	// Return is EndEquity / StartEquity - 1
+	Return decimal.Decimal
+	// MaxDrawdown is the largest fall of equity from a peak, as a fraction of the peak
+	MaxDrawdown decimal.Decimal
+	// Turnover is the traded volume in quote currency
+	Turnover decimal.Decimal
+	Fees     map[Currency]decimal.Decimal
+	Trades   []PaperFill
+}
+
+// Backtest runs a strategy against historical klines and trades with
+// a simulated order matcher. It implements Trading, so that a strategy
+// can be written once and run by a Backtest, a PaperTrader or Namebase.
+//
+// Orders are matched by events after the latency has passed. Limit orders
+// are filled as maker at their price when the price crosses it: against
+// klines when the low is below a buy or the high above a sell, and against
+// trades when the trade price is beyond it, up to the trade quantity.
+// Market orders are filled as taker at the open price of the next kline
+// or the price of the next trade.
+type Backtest struct {
+	cfg     BacktestConfig
+	latency int64
+	now     int64
+	price   decimal.Decimal
+	fills   []PaperFill
+	ran     bool
+	ledger
+}
+
+// NewBacktest creates a Backtest, nb provides the symbol precisions
+// and is not called during the backtest
+func NewBacktest(nb *Namebase, cfg BacktestConfig) (*Backtest, error) {
+	if _, ok := nb.symbolInfo[cfg.Pair]; !ok {
+		return nil, errors.New("unsupported trading pair: " + cfg.Pair.String())
+	}
+
+	bt := &Backtest{
+		cfg:     cfg,
+		latency: int64(cfg.Latency / time.Millisecond),
+	}
+	bt.ledger = newLedger(nb, cfg.Balances, cfg.MakerFee, cfg.TakerFee, func() int64 {
+		return bt.now
+	})
+
+	return bt, nil
+}
+
+// Run feeds klines and trades, merged by time, to the matcher and the strategy.
+// Either may be empty, a Backtest can only be run once
+func (bt *Backtest) Run(klines []Kline, trades []Trade, strategy Strategy) (*BacktestReport, error) {
+	if bt.ran {
+		return nil, errors.New("backtest has already run")
+	}
+	bt.ran = true
+
+	var events []MarketEvent
+	for i := range klines {
+		events = append(events, MarketEvent{Time: klines[i].CloseTime, Kline: &klines[i]})
+	}
+	for i := range trades {
+		events = append(events, MarketEvent{Time: trades[i].CreatedAt, Trade: &trades[i]})
+	}
+	if len(events) == 0 {
+		return nil, errors.New("no market data")
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+
+	report := &BacktestReport{
+		Turnover: decimal.Zero,
+		Fees:     make(map[Currency]decimal.Decimal),
+	}
+	peak, drawdown := decimal.Zero, decimal.Zero
+
+	for _, ev := range events {
+		bt.now = ev.Time
+		if ev.Kline != nil {
+			bt.matchKline(ev.Kline)
+			bt.price = ev.Kline.ClosePrice
+		} else {
+			bt.matchTrade(ev.Trade)
+			bt.price = ev.Trade.Price
+		}
+
+		if strategy != nil {
+			strategy(bt, ev)
+		}
+
+		equity := bt.equity()
+		report.Equity = append(report.Equity, EquityPoint{Time: ev.Time, Equity: equity})
+
+		if equity.GreaterThan(peak) {
+			peak = equity
+		} else if peak.IsPositive() {
+			if dd := peak.Sub(equity).Div(peak); dd.GreaterThan(drawdown) {
+				drawdown = dd
+			}
+		}
+	}
+
+	report.StartEquity = report.Equity[0].Equity
+	report.EndEquity = report.Equity[len(report.Equity)-1].Equity
+	report.Return = decimal.Zero
+	if report.StartEquity.IsPositive() {
+		report.Return = report.EndEquity.Div(report.StartEquity).Sub(decimal.New(1, 0))
+	}
+	report.MaxDrawdown = drawdown
+	report.Trades = bt.fills
+
+	for _, f := range bt.fills {
+		report.Turnover = report.Turnover.Add(f.QuoteQuantity)
+		asset := NewCurrency(f.CommissionAsset)
+		report.Fees[asset] = report.Fees[asset].Add(f.Commission)
+	}
+
+	return report, nil
+}
+
+// Now returns the time of the current event in milliseconds
+func (bt *Backtest) Now() int64 {
+	return bt.now
+}
+
+// LimitBuy buy token at limited price
+func (bt *Backtest) LimitBuy(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return bt.place(OrderRequest{
+		Pair: pair, Side: BuyOrder, Type: OrderTypeLimit, Quantity: amount, Price: price,
+	})
+}
+
+// LimitSell sell token at limited price
+func (bt *Backtest) LimitSell(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return bt.place(OrderRequest{
+		Pair: pair, Side: SellOrder, Type: OrderTypeLimit, Quantity: amount, Price: price,
+	})
+}
+
+// MarketBuy buy token at market price
+func (bt *Backtest) MarketBuy(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return bt.place(OrderRequest{
+		Pair: pair, Side: BuyOrder, Type: OrderTypeMarket, Quantity: amount,
+	})
+}
+
+// MarketSell sells token at market price
+func (bt *Backtest) MarketSell(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return bt.place(OrderRequest{
+		Pair: pair, Side: SellOrder, Type: OrderTypeMarket, Quantity: amount,
+	})
+}
+
+// CancelOrder cancels an open order immediately, regardless of the latency
+func (bt *Backtest) CancelOrder(orderID int, pair CurrencyPair) (bool, error) {
+	if err := bt.cancel(orderID, pair); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetOrder queries order detail
+func (bt *Backtest) GetOrder(orderID int, pair CurrencyPair) (*Order, error) {
+	o, err := bt.order(orderID, pair)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.copy(), nil
+}
+
+// OpenOrders lists all open orders of a trading pair
+func (bt *Backtest) OpenOrders(pair CurrencyPair) ([]Order, error) {
+	return copyOrders(bt.openOrders(pair)), nil
+}
+
+// GetAccount returns the simulated balances
+func (bt *Backtest) GetAccount() (*Account, error) {
+	return bt.account(), nil
+}
+
+func (bt *Backtest) place(req OrderRequest) (*Order, error) {
+	if req.Pair != bt.cfg.Pair {
+		return nil, errors.New("backtest does not trade " + req.Pair.String())
+	}
+
+	o, err := bt.newOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	o.activeAt = bt.now + bt.latency
+
+	return o.copy(), nil
+}
+
+func (bt *Backtest) matchKline(k *Kline) {
+	for _, o := range bt.openOrders(bt.cfg.Pair) {
+		if o.activeAt > k.OpenTime {
+			continue
+		}
+
+		switch {
+		case o.Type == OrderTypeMarket:
+			bt.fillMarket(o, k.OpenPrice)
+		case o.Side == BuyOrder && k.LowPrice.LessThan(o.Price),
+			o.Side == SellOrder && k.HighPrice.GreaterThan(o.Price):
+			bt.fills = append(bt.fills, bt.fill(o, o.Price, o.RemainingQuantity(), true))
+		}
+	}
+}
+
+func (bt *Backtest) matchTrade(t *Trade) {
+	available := t.Quantity
+	for _, o := range bt.openOrders(bt.cfg.Pair) {
+		if o.activeAt > t.CreatedAt {
+			continue
+		}
+
+		if o.Type == OrderTypeMarket {
+			bt.fillMarket(o, t.Price)
+			continue
+		}
+
+		if !available.IsPositive() {
+			continue
+		}
+
+		if o.Side == BuyOrder && t.Price.LessThan(o.Price) ||
+			o.Side == SellOrder && t.Price.GreaterThan(o.Price) {
+			qty := decimal.Min(available, o.RemainingQuantity())
+			available = available.Sub(qty)
+			bt.fills = append(bt.fills, bt.fill(o, o.Price, qty, true))
+		}
+	}
+}
+
+// fillMarket fills what the balance allows of a market order at price,
+// and expires the rest
+func (bt *Backtest) fillMarket(o *paperOrder, price decimal.Decimal) {
+	if qty := bt.fillable(o, price); qty.IsPositive() {
+		bt.fills = append(bt.fills, bt.fill(o, price, qty, false))
+	}
+
+	if o.IsOpen() {
+		bt.expire(o)
+	}
+}
+
+// equity values the base and quote balances at the last price
+func (bt *Backtest) equity() decimal.Decimal {
+	base := bt.balance(bt.cfg.Pair.Base).Total()
+	quote := bt.balance(bt.cfg.Pair.Quote).Total()
+
+	return quote.Add(base.Mul(bt.price))
+}
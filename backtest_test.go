@@ -0,0 +1,102 @@
+package namebase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBacktest(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	client := &Namebase{
+		symbolInfo: map[CurrencyPair]symbolInfo{
+			pair: {BasePrecision: 6, QuotePrecision: 8},
+		},
+	}
+
+	bt, err := NewBacktest(client, BacktestConfig{
+		Pair:     pair,
+		Balances: map[Currency]decimal.Decimal{"BTC": decimal.NewFromFloat(0.01)},
+		MakerFee: 10,
+		TakerFee: 20,
+		Latency:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kline := func(open int64, o, h, l, c string) Kline {
+		return Kline{
+			OpenTime:   open,
+			CloseTime:  open + 59999,
+			OpenPrice:  decimal.RequireFromString(o),
+			HighPrice:  decimal.RequireFromString(h),
+			LowPrice:   decimal.RequireFromString(l),
+			ClosePrice: decimal.RequireFromString(c),
+		}
+	}
+	klines := []Kline{
+		kline(0, "0.00001", "0.00001", "0.00001", "0.00001"),
+		// the buy is not active yet
+		kline(60000, "0.00001", "0.000011", "0.000008", "0.000009"),
+		kline(120000, "0.000009", "0.000011", "0.000008", "0.000008"),
+		kline(180000, "0.00001", "0.000012", "0.00001", "0.000011"),
+	}
+	trades := []Trade{
+		{Price: decimal.RequireFromString("0.0000125"), Quantity: decimal.New(30, 0), CreatedAt: 240000},
+	}
+
+	var buyID, sellID int
+	report, err := bt.Run(klines, trades, func(bt *Backtest, ev MarketEvent) {
+		switch ev.Time {
+		case 59999:
+			o, err := bt.LimitBuy(decimal.New(100, 0), decimal.RequireFromString("0.0000085"), pair)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buyID = o.OrderID
+		case 179999:
+			o, err := bt.LimitSell(decimal.New(50, 0), decimal.RequireFromString("0.000012"), pair)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sellID = o.OrderID
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o, _ := bt.GetOrder(buyID, pair); o.Status != OrderStatusFilled || o.Fills[0].Price.String() != "0.0000085" {
+		t.Errorf("unexpected buy: %+v", o)
+	}
+
+	// the trade crosses the sell, but only for 30
+	if o, _ := bt.GetOrder(sellID, pair); o.Status != OrderStatusPartiallyFilled || o.ExecutedQuantity.String() != "30" {
+		t.Errorf("unexpected sell: %+v", o)
+	}
+
+	if len(report.Trades) != 2 || len(report.Equity) != 5 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// 100 * 0.0000085 + 30 * 0.000012
+	if report.Turnover.String() != "0.00121" {
+		t.Errorf("turnover: %s", report.Turnover)
+	}
+
+	if report.Fees["HNS"].String() != "0.1" || report.Fees["BTC"].String() != "0.00000036" {
+		t.Errorf("fees: %+v", report.Fees)
+	}
+
+	// 0.01 - 0.00085 + 0.00036 - 0.00000036 BTC and 69.9 HNS at 0.0000125
+	if report.EndEquity.String() != "0.01038339" {
+		t.Errorf("end equity: %s", report.EndEquity)
+	}
+
+	// the equity fell from 0.01 to 0.00915 + 99.9 * 0.000008 after the buy
+	if report.MaxDrawdown.String() != "0.00508" || report.Return.String() != "0.038339" {
+		t.Errorf("drawdown: %s, return: %s", report.MaxDrawdown, report.Return)
+	}
+}
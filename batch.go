@@ -0,0 +1,123 @@
+package namebase
+
+import (
+	"fmt"
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// OrderResult is the outcome of a single order placed by PlaceOrders
+type OrderResult struct {
+	Request OrderRequest
+	Order   *Order
+	Err     error
+	// Canceled is set when the order was placed but canceled afterwards
+	// because another order of an all-or-nothing batch failed
+	Canceled bool
+}
+
+type batchOptions struct {
+	concurrency  int
+	allOrNothing bool
+}
+
+// BatchOption customizes PlaceOrders
+type BatchOption func(*batchOptions)
+
+// WithConcurrency sets how many orders are submitted at the same time
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// AllOrNothing cancels every successfully placed order
+// if any order of the batch fails
+func AllOrNothing() BatchOption {
+	return func(o *batchOptions) {
+		o.allOrNothing = true
+	}
+}
+
+// PlaceOrders validates all requests up front, then submits them concurrently.
+// Results are in the same order as reqs. The returned error is non-nil
+// if validation fails, in which case nothing is submitted, or if any order failed.
+func (nb *Namebase) PlaceOrders(reqs []OrderRequest, opts ...BatchOption) ([]OrderResult, error) {
+	options := batchOptions{concurrency: defaultBatchConcurrency}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for i, req := range reqs {
+		if _, err := nb.orderParams(req); err != nil {
+			return nil, fmt.Errorf("order %d: %v", i, err)
+		}
+	}
+
+	results := make([]OrderResult, len(reqs))
+	sem := make(chan struct{}, options.concurrency)
+	var wg sync.WaitGroup
+
+	for i := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			o, err := nb.submitOrder(reqs[i])
+			results[i] = OrderResult{Request: reqs[i], Order: o, Err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return results, nil
+	}
+
+	if options.allOrNothing {
+		nb.cancelPlaced(results, options.concurrency)
+	}
+
+	return results, fmt.Errorf("%d of %d orders failed", failed, len(reqs))
+}
+
+// cancelPlaced cancels every order of results which has been placed
+func (nb *Namebase) cancelPlaced(results []OrderResult, concurrency int) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range results {
+		if results[i].Order == nil {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *OrderResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if _, err := nb.CancelOrder(r.Order.OrderID, r.Request.Pair); err != nil {
+				r.Err = fmt.Errorf("failed to cancel after batch failure: %v", err)
+				return
+			}
+			r.Canceled = true
+		}(&results[i])
+	}
+	wg.Wait()
+}
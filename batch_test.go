@@ -0,0 +1,131 @@
+package namebase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPlaceOrdersValidation(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	client := &Namebase{
+		symbolInfo: map[CurrencyPair]symbolInfo{
+			pair: {BasePrecision: 6, QuotePrecision: 8},
+		},
+	}
+
+	reqs := []OrderRequest{
+		{Pair: pair, Side: BuyOrder, Type: OrderTypeLimit,
+			Quantity: decimal.NewFromFloat(100), Price: decimal.NewFromFloat(0.00009)},
+		{Pair: pair, Side: SellOrder, Type: OrderTypeLimit,
+			Quantity: decimal.NewFromFloat(100), Price: decimal.Zero},
+	}
+
+	if results, err := client.PlaceOrders(reqs); err == nil {
+		t.Errorf("expected validation error, got results: %+v", results)
+	}
+}
+
+func batchRequests(n int) []OrderRequest {
+	pair := NewCurrencyPair("hns", "btc")
+	reqs := make([]OrderRequest, n)
+	for i := range reqs {
+		reqs[i] = OrderRequest{Pair: pair, Side: BuyOrder, Type: OrderTypeLimit,
+			Quantity: decimal.New(100, 0), Price: decimal.New(int64(90+i), -7)}
+	}
+
+	return reqs
+}
+
+func TestPlaceOrders(t *testing.T) {
+	reqs := batchRequests(6)
+	results, err := nb.PlaceOrders(reqs, WithConcurrency(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[int]bool)
+	for i, r := range results {
+		if r.Err != nil || r.Order == nil || r.Canceled {
+			t.Fatalf("unexpected result %d: %+v", i, r)
+		}
+
+		if !r.Order.Price.Equal(reqs[i].Price) || r.Order.Status != OrderStatusNew {
+			t.Errorf("result %d does not match its request: %+v", i, r.Order)
+		}
+
+		if ids[r.Order.OrderID] {
+			t.Errorf("order %d is returned twice", r.Order.OrderID)
+		}
+		ids[r.Order.OrderID] = true
+	}
+}
+
+func TestPlaceOrdersPartialFailure(t *testing.T) {
+	srv.InjectAPIError("POST", "/api/v0/order", "INSUFFICIENT_FUNDS", "insufficient funds")
+
+	results, err := nb.PlaceOrders(batchRequests(3))
+	if err == nil {
+		t.Fatal("partial failure is not reported")
+	}
+
+	failed := 0
+	for i, r := range results {
+		if r.Err != nil {
+			failed++
+			if r.Order != nil || r.Err.Error() != "insufficient funds" {
+				t.Errorf("unexpected failed result %d: %+v", i, r)
+			}
+			continue
+		}
+
+		// the other orders are kept
+		if o, _ := srv.Order(r.Order.OrderID); r.Canceled || o.Status != "NEW" {
+			t.Errorf("unexpected result %d: %+v, server status %s", i, r, o.Status)
+		}
+	}
+
+	if failed != 1 {
+		t.Errorf("expected 1 failed order, got %d", failed)
+	}
+}
+
+func TestPlaceOrdersAllOrNothing(t *testing.T) {
+	srv.InjectAPIError("POST", "/api/v0/order", "INSUFFICIENT_FUNDS", "insufficient funds")
+	srv.InjectAPIError("DELETE", "/api/v0/order", "INTERNAL", "try again")
+
+	results, err := nb.PlaceOrders(batchRequests(3), AllOrNothing())
+	if err == nil {
+		t.Fatal("partial failure is not reported")
+	}
+
+	var placeFailed, canceled, cancelFailed int
+	for i, r := range results {
+		switch {
+		case r.Order == nil:
+			placeFailed++
+		case r.Canceled:
+			canceled++
+			if r.Err != nil {
+				t.Errorf("canceled result %d has error: %v", i, r.Err)
+			}
+			if o, _ := srv.Order(r.Order.OrderID); o.Status != "CANCELED" {
+				t.Errorf("order %d is %s", o.OrderID, o.Status)
+			}
+		default:
+			cancelFailed++
+			if r.Err == nil || !strings.Contains(r.Err.Error(), "try again") {
+				t.Errorf("unexpected cancel error of result %d: %v", i, r.Err)
+			}
+			if o, _ := srv.Order(r.Order.OrderID); o.Status != "NEW" {
+				t.Errorf("order %d is %s", o.OrderID, o.Status)
+			}
+		}
+	}
+
+	if placeFailed != 1 || canceled != 1 || cancelFailed != 1 {
+		t.Errorf("expected one failed, one canceled and one left order, got %d, %d and %d",
+			placeFailed, canceled, cancelFailed)
+	}
+}
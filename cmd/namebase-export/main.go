@@ -0,0 +1,158 @@
+// Command namebase-export writes klines, recorded trades or an order book
+// snapshot of a trading pair to a CSV or Parquet file
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sniperem/namebase"
+)
+
+func main() {
+	kind := flag.String("type", "klines", "data to export: klines, trades or depth")
+	format := flag.String("format", "csv", "output format: csv or parquet")
+	output := flag.String("o", "", "output file, stdout by default")
+	base := flag.String("base", "hns", "base currency of the pair")
+	quote := flag.String("quote", "btc", "quote currency of the pair")
+	interval := flag.String("interval", "1h", "kline interval")
+	limit := flag.Int("limit", 0, "number of latest klines, when no start is given")
+	start := flag.String("start", "", "start of the klines, RFC3339")
+	end := flag.String("end", "", "end of the klines, RFC3339, now by default")
+	size := flag.Int("size", 0, "number of order book levels")
+	recording := flag.String("recording", "", "recording dir to read trades from")
+	flag.Parse()
+
+	if *format != "csv" && *format != "parquet" {
+		log.Fatalf("unsupported format: %s", *format)
+	}
+
+	pair := namebase.NewCurrencyPair(*base, *quote)
+
+	var export func(w io.Writer) error
+	switch *kind {
+	case "klines":
+		export = func(w io.Writer) error {
+			return exportKlines(w, *format, pair, namebase.KlineInterval(*interval), *limit, *start, *end)
+		}
+	case "trades":
+		export = func(w io.Writer) error {
+			return exportTrades(w, *format, pair, *recording)
+		}
+	case "depth":
+		export = func(w io.Writer) error {
+			return exportDepth(w, *format, pair, *size)
+		}
+	default:
+		log.Fatalf("unsupported type: %s", *kind)
+	}
+
+	if err := writeOutput(*output, export); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeOutput runs export to stdout if path is empty. Otherwise it exports
+// to a temporary file next to path, which replaces path only on success,
+// so that a failed export leaves no truncated file behind
+func writeOutput(path string, export func(w io.Writer) error) error {
+	if path == "" {
+		return export(os.Stdout)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	// temporary files are only readable by their owner
+	if err = f.Chmod(0644); err == nil {
+		err = export(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
+
+func exportKlines(w io.Writer, format string, pair namebase.CurrencyPair,
+	interval namebase.KlineInterval, limit int, start, end string) error {
+	nb, err := namebase.NewClient("", "")
+	if err != nil {
+		return err
+	}
+
+	var klines []namebase.Kline
+	if start == "" {
+		klines, err = nb.GetKlines(pair, interval, limit)
+	} else {
+		from, to := time.Time{}, time.Now()
+		if from, err = time.Parse(time.RFC3339, start); err != nil {
+			return err
+		}
+
+		if end != "" {
+			if to, err = time.Parse(time.RFC3339, end); err != nil {
+				return err
+			}
+		}
+
+		klines, err = nb.GetKlinesRange(pair, interval, from, to)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if format == "parquet" {
+		return namebase.WriteKlinesParquet(w, klines)
+	}
+	return namebase.WriteKlinesCSV(w, klines)
+}
+
+func exportTrades(w io.Writer, format string, pair namebase.CurrencyPair, dir string) error {
+	if dir == "" {
+		return errors.New("trades are exported from a recording, -recording is required")
+	}
+
+	trades, err := namebase.RecordedTrades(dir, pair)
+	if err != nil {
+		return err
+	}
+
+	if format == "parquet" {
+		return namebase.WriteTradesParquet(w, trades)
+	}
+	return namebase.WriteTradesCSV(w, trades)
+}
+
+func exportDepth(w io.Writer, format string, pair namebase.CurrencyPair, size int) error {
+	nb, err := namebase.NewClient("", "")
+	if err != nil {
+		return err
+	}
+
+	d, err := nb.GetDepth(pair, size)
+	if err != nil {
+		return err
+	}
+
+	if format == "parquet" {
+		return namebase.WriteDepthParquet(w, *d)
+	}
+	return namebase.WriteDepthCSV(w, *d)
+}
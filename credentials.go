@@ -0,0 +1,314 @@
+package namebase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Credentials is an API key and its secret
+type Credentials struct {
+	Key    string `json:"key"`
+	Secret string `json:"secret"`
+}
+
+// CredentialProvider supplies the credentials of signed requests. It is
+// asked before every signed request, so rotated credentials are used from
+// the next request on, without recreating the client
+type CredentialProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// WithCredentials signs requests with the credentials of p
+// instead of the key and secret passed to NewClient
+func WithCredentials(p CredentialProvider) ClientOption {
+	return func(nb *Namebase) {
+		nb.creds = &credentialHolder{p: p}
+	}
+}
+
+// SetCredentials replaces the credential provider of the client at runtime,
+// requests in flight finish with the previous credentials and streams,
+// which are not signed, are not interrupted
+func (nb *Namebase) SetCredentials(p CredentialProvider) {
+	if nb.creds == nil {
+		nb.creds = &credentialHolder{}
+	}
+	nb.creds.set(p)
+}
+
+// credentialHolder lets the provider of a client be replaced concurrently
+type credentialHolder struct {
+	mu sync.RWMutex
+	p  CredentialProvider
+}
+
+func (h *credentialHolder) get() (Credentials, error) {
+	if h == nil {
+		return Credentials{}, errors.New("no credentials")
+	}
+
+	h.mu.RLock()
+	p := h.p
+	h.mu.RUnlock()
+
+	if p == nil {
+		return Credentials{}, errors.New("no credentials")
+	}
+
+	return p.Credentials()
+}
+
+func (h *credentialHolder) set(p CredentialProvider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.p = p
+}
+
+// StaticCredentials provides the same credentials until they are rotated
+type StaticCredentials struct {
+	mu sync.RWMutex
+	c  Credentials
+}
+
+// NewStaticCredentials creates a StaticCredentials
+func NewStaticCredentials(key, secret string) *StaticCredentials {
+	return &StaticCredentials{c: Credentials{Key: key, Secret: secret}}
+}
+
+// Credentials implements CredentialProvider
+func (s *StaticCredentials) Credentials() (Credentials, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.c, nil
+}
+
+// Rotate replaces the credentials
+func (s *StaticCredentials) Rotate(key, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.c = Credentials{Key: key, Secret: secret}
+}
+
+// Environment variables read by EnvCredentials by default
+const (
+	EnvAPIKey    = "NAMEBASE_API_KEY"
+	EnvSecretKey = "NAMEBASE_SECRET_KEY"
+)
+
+// EnvCredentials reads the credentials from environment variables on every
+// request, empty names default to EnvAPIKey and EnvSecretKey
+func EnvCredentials(keyVar, secretVar string) CredentialProvider {
+	if keyVar == "" {
+		keyVar = EnvAPIKey
+	}
+	if secretVar == "" {
+		secretVar = EnvSecretKey
+	}
+
+	return envCredentials{keyVar, secretVar}
+}
+
+type envCredentials struct {
+	keyVar, secretVar string
+}
+
+func (e envCredentials) Credentials() (Credentials, error) {
+	c := Credentials{Key: os.Getenv(e.keyVar), Secret: os.Getenv(e.secretVar)}
+	if c.Key == "" || c.Secret == "" {
+		return Credentials{}, fmt.Errorf("%s and %s must be set", e.keyVar, e.secretVar)
+	}
+
+	return c, nil
+}
+
+// FileCredentials reads the credentials from a JSON file {"key": ..., "secret": ...},
+// which must not be accessible by group or others. The file is read again when
+// it is modified, so credentials are rotated by replacing it
+func FileCredentials(path string) CredentialProvider {
+	return &fileCredentials{path: path}
+}
+
+type fileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	c       Credentials
+}
+
+func (f *fileCredentials) Credentials() (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fi, err := checkPrivate(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if !fi.ModTime().Equal(f.modTime) {
+		data, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		var c Credentials
+		if err := json.Unmarshal(data, &c); err != nil {
+			return Credentials{}, fmt.Errorf("credentials file %s: %v", f.path, err)
+		}
+		if c.Key == "" || c.Secret == "" {
+			return Credentials{}, fmt.Errorf("credentials file %s: key and secret are required", f.path)
+		}
+
+		f.c, f.modTime = c, fi.ModTime()
+	}
+
+	return f.c, nil
+}
+
+// checkPrivate fails if the file can be accessed by group or others,
+// permissions are not checked on windows
+func checkPrivate(path string) (os.FileInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by others (%s), it should be 0600",
+			path, fi.Mode().Perm())
+	}
+
+	return fi, nil
+}
+
+// keystore is the file written by WriteKeystore, the credentials are
+// encrypted with AES-256-GCM by a key derived from the passphrase
+// with PBKDF2-HMAC-SHA256
+type keystore struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const keystoreIterations = 200000
+
+// WriteKeystore encrypts the credentials with passphrase into a keystore file,
+// readable only by its owner
+func WriteKeystore(path string, c Credentials, passphrase []byte) error {
+	ks := keystore{Version: 1, Iterations: keystoreIterations, Salt: make([]byte, 16)}
+	if _, err := io.ReadFull(rand.Reader, ks.Salt); err != nil {
+		return err
+	}
+
+	gcm, err := keystoreCipher(passphrase, ks.Salt, ks.Iterations)
+	if err != nil {
+		return err
+	}
+
+	ks.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, ks.Nonce); err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	ks.Ciphertext = gcm.Seal(nil, ks.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// KeystoreCredentials decrypts the credentials of a keystore file written by
+// WriteKeystore. The file is decrypted once, and again when it is modified
+func KeystoreCredentials(path string, passphrase []byte) CredentialProvider {
+	return &keystoreCredentials{path: path, passphrase: passphrase}
+}
+
+type keystoreCredentials struct {
+	path       string
+	passphrase []byte
+
+	mu      sync.Mutex
+	modTime time.Time
+	c       Credentials
+}
+
+func (k *keystoreCredentials) Credentials() (Credentials, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	fi, err := checkPrivate(k.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if fi.ModTime().Equal(k.modTime) {
+		return k.c, nil
+	}
+
+	data, err := ioutil.ReadFile(k.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var ks keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return Credentials{}, fmt.Errorf("keystore %s: %v", k.path, err)
+	}
+	if ks.Version != 1 || ks.Iterations <= 0 {
+		return Credentials{}, fmt.Errorf("keystore %s: unsupported version %d", k.path, ks.Version)
+	}
+
+	gcm, err := keystoreCipher(k.passphrase, ks.Salt, ks.Iterations)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if len(ks.Nonce) != gcm.NonceSize() {
+		return Credentials{}, fmt.Errorf("keystore %s: invalid nonce", k.path)
+	}
+
+	plain, err := gcm.Open(nil, ks.Nonce, ks.Ciphertext, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("keystore %s: wrong passphrase or corrupted file", k.path)
+	}
+
+	var c Credentials
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return Credentials{}, fmt.Errorf("keystore %s: %v", k.path, err)
+	}
+
+	k.c, k.modTime = c, fi.ModTime()
+	return c, nil
+}
+
+func keystoreCipher(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,101 @@
+package namebase
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetCredentialsUninitialized(t *testing.T) {
+	client := &Namebase{}
+	client.SetCredentials(NewStaticCredentials("key", "secret"))
+
+	if c, err := client.creds.get(); err != nil || c.Key != "key" {
+		t.Errorf("unexpected credentials: %+v, %v", c, err)
+	}
+}
+
+func TestRotateCredentials(t *testing.T) {
+	srv.SetCredentials("key2", "secret2")
+	defer srv.SetCredentials("", "")
+
+	creds := NewStaticCredentials("key1", "secret1")
+	client, err := NewClient("", "", WithBaseURL(srv.URL), WithCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetAccount(); err == nil {
+		t.Error("old credentials are accepted")
+	}
+
+	creds.Rotate("key2", "secret2")
+	if _, err := client.GetAccount(); err != nil {
+		t.Error(err)
+	}
+
+	client.SetCredentials(EnvCredentials("TEST_NAMEBASE_KEY", "TEST_NAMEBASE_SECRET"))
+	if _, err := client.GetAccount(); err == nil {
+		t.Error("unset environment is accepted")
+	}
+
+	os.Setenv("TEST_NAMEBASE_KEY", "key2")
+	os.Setenv("TEST_NAMEBASE_SECRET", "secret2")
+	defer os.Unsetenv("TEST_NAMEBASE_KEY")
+	defer os.Unsetenv("TEST_NAMEBASE_SECRET")
+
+	if _, err := client.GetAccount(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials.json")
+	ioutil.WriteFile(path, []byte(`{"key": "k1", "secret": "s1"}`), 0644)
+
+	p := FileCredentials(path)
+	if _, err := p.Credentials(); err == nil {
+		t.Error("file readable by others is accepted")
+	}
+
+	os.Chmod(path, 0600)
+	if c, err := p.Credentials(); err != nil || c.Key != "k1" {
+		t.Errorf("unexpected credentials: %+v, %v", c, err)
+	}
+
+	ioutil.WriteFile(path, []byte(`{"key": "k2", "secret": "s2"}`), 0600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if c, err := p.Credentials(); err != nil || c.Key != "k2" {
+		t.Errorf("credentials are not rotated: %+v, %v", c, err)
+	}
+}
+
+func TestKeystoreCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keystore.json")
+	if err := WriteKeystore(path, Credentials{Key: "k1", Secret: "s1"}, []byte("pass")); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := KeystoreCredentials(path, []byte("pass")).Credentials(); err != nil || c.Secret != "s1" {
+		t.Errorf("unexpected credentials: %+v, %v", c, err)
+	}
+
+	if _, err := KeystoreCredentials(path, []byte("wrong")).Credentials(); err == nil {
+		t.Error("wrong passphrase is accepted")
+	}
+}
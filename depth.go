@@ -0,0 +1,85 @@
+package namebase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrDepthGap is returned when a diff of the depth stream does not follow
+// the last applied event, the local book is then out of sync and must be
+// reset with a new snapshot
+var ErrDepthGap = errors.New("gap in depth stream")
+
+// depthBook maintains a local order book from a snapshot and the diffs
+// of the depth stream, it is shared by SubDepth and Replayer
+type depthBook struct {
+	snapshot *Depth
+	d        struct {
+		Depth
+		EventType    string
+		EventTime    int64
+		Symbol       string
+		FirstEventID int64
+	}
+}
+
+// reset replaces the book with a new snapshot
+func (b *depthBook) reset(snapshot *Depth) {
+	b.snapshot = snapshot
+}
+
+// apply applies the diff message to the book, and returns a copy of the book
+// and true if the message carries any update. Diffs already contained in the
+// book are skipped, and a diff starting after the next event returns ErrDepthGap
+// without changing the book. A diff overlapping the book is applied, since
+// its levels replace the ones of the book.
+func (b *depthBook) apply(data []byte) (Depth, bool, error) {
+	d := &b.d
+	snapshot := b.snapshot
+
+	// reset
+	d.Asks = d.Asks[:0]
+	d.Bids = d.Bids[:0]
+	if err := json.Unmarshal(data, d); err != nil {
+		return Depth{}, false, err
+	}
+
+	if len(d.Asks) == 0 && len(d.Bids) == 0 {
+		// FirstEventID = -1
+		return Depth{}, false, nil
+	}
+
+	if d.LastEventID <= snapshot.LastEventID {
+		return Depth{}, false, nil
+	}
+
+	if d.FirstEventID > snapshot.LastEventID+1 {
+		return Depth{}, false, fmt.Errorf("%w: expected event %d, got events %d to %d",
+			ErrDepthGap, snapshot.LastEventID+1, d.FirstEventID, d.LastEventID)
+	}
+
+	for _, ask := range d.Asks {
+		snapshot.Asks = updateDepth(snapshot.Asks, ask, true)
+	}
+
+	for _, bid := range d.Bids {
+		snapshot.Bids = updateDepth(snapshot.Bids, bid, false)
+	}
+
+	snapshot.LastEventID = d.LastEventID
+
+	// deep copy
+	depth := Depth{
+		//Pair:    pair,
+		Ts:          d.EventTime,
+		LastEventID: snapshot.LastEventID,
+		Asks:        make([]DepthRecord, len(snapshot.Asks)),
+		Bids:        make([]DepthRecord, len(snapshot.Bids)),
+	}
+
+	copy(depth.Asks, snapshot.Asks)
+	copy(depth.Bids, snapshot.Bids)
+
+	return depth, true, nil
+}
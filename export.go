@@ -0,0 +1,169 @@
+package namebase
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// Column schemas of the exported files. Prices and quantities are written
+// as decimal strings to keep their exact precision
+var (
+	KlineColumns = []string{"openTime", "closeTime", "openPrice", "highPrice", "lowPrice",
+		"closePrice", "volume", "quoteVolume", "numberOfTrades"}
+	TradeColumns = []string{"tradeId", "createdAt", "price", "quantity", "quoteQuantity",
+		"isBuyerMaker"}
+	DepthColumns = []string{"ts", "lastEventId", "side", "level", "price", "amount"}
+)
+
+// WriteKlinesCSV writes klines as CSV with a header of KlineColumns
+func WriteKlinesCSV(w io.Writer, klines []Kline) error {
+	cw := csv.NewWriter(w)
+	cw.Write(KlineColumns)
+	for _, k := range klines {
+		cw.Write([]string{
+			strconv.FormatInt(k.OpenTime, 10),
+			strconv.FormatInt(k.CloseTime, 10),
+			k.OpenPrice.String(),
+			k.HighPrice.String(),
+			k.LowPrice.String(),
+			k.ClosePrice.String(),
+			k.Volume.String(),
+			k.QuoteVolume.String(),
+			strconv.Itoa(k.NumberOfTrades),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTradesCSV writes trades as CSV with a header of TradeColumns
+func WriteTradesCSV(w io.Writer, trades []Trade) error {
+	cw := csv.NewWriter(w)
+	cw.Write(TradeColumns)
+	for _, t := range trades {
+		cw.Write([]string{
+			strconv.Itoa(t.TradeID),
+			strconv.FormatInt(t.CreatedAt, 10),
+			t.Price.String(),
+			t.Quantity.String(),
+			t.QuoteQuantity.String(),
+			strconv.FormatBool(t.IsBuyerMaker),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDepthCSV writes the order book as CSV with a header of DepthColumns,
+// one row per price level, level 0 being the best price of each side
+func WriteDepthCSV(w io.Writer, depths ...Depth) error {
+	cw := csv.NewWriter(w)
+	cw.Write(DepthColumns)
+	for _, d := range depths {
+		forEachLevel(d, func(side string, level int, r DepthRecord) {
+			cw.Write([]string{
+				strconv.FormatInt(d.Ts, 10),
+				strconv.FormatInt(d.LastEventID, 10),
+				side,
+				strconv.Itoa(level),
+				r.Price.String(),
+				r.Amount.String(),
+			})
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteKlinesParquet writes klines as a parquet file with KlineColumns,
+// times are timestamps in milliseconds and decimals are UTF8 strings
+func WriteKlinesParquet(w io.Writer, klines []Kline) error {
+	openTime := newParquetColumn("openTime", parquetInt64, parquetTimestampMillis)
+	closeTime := newParquetColumn("closeTime", parquetInt64, parquetTimestampMillis)
+	openPrice := newParquetColumn("openPrice", parquetByteArray, parquetUTF8)
+	highPrice := newParquetColumn("highPrice", parquetByteArray, parquetUTF8)
+	lowPrice := newParquetColumn("lowPrice", parquetByteArray, parquetUTF8)
+	closePrice := newParquetColumn("closePrice", parquetByteArray, parquetUTF8)
+	volume := newParquetColumn("volume", parquetByteArray, parquetUTF8)
+	quoteVolume := newParquetColumn("quoteVolume", parquetByteArray, parquetUTF8)
+	trades := newParquetColumn("numberOfTrades", parquetInt32, parquetNone)
+
+	for _, k := range klines {
+		openTime.appendInt64(k.OpenTime)
+		closeTime.appendInt64(k.CloseTime)
+		openPrice.appendString(k.OpenPrice.String())
+		highPrice.appendString(k.HighPrice.String())
+		lowPrice.appendString(k.LowPrice.String())
+		closePrice.appendString(k.ClosePrice.String())
+		volume.appendString(k.Volume.String())
+		quoteVolume.appendString(k.QuoteVolume.String())
+		trades.appendInt32(int32(k.NumberOfTrades))
+	}
+
+	return writeParquet(w, []*parquetColumn{openTime, closeTime, openPrice, highPrice,
+		lowPrice, closePrice, volume, quoteVolume, trades}, len(klines))
+}
+
+// WriteTradesParquet writes trades as a parquet file with TradeColumns
+func WriteTradesParquet(w io.Writer, trades []Trade) error {
+	tradeID := newParquetColumn("tradeId", parquetInt64, parquetNone)
+	createdAt := newParquetColumn("createdAt", parquetInt64, parquetTimestampMillis)
+	price := newParquetColumn("price", parquetByteArray, parquetUTF8)
+	quantity := newParquetColumn("quantity", parquetByteArray, parquetUTF8)
+	quoteQuantity := newParquetColumn("quoteQuantity", parquetByteArray, parquetUTF8)
+	isBuyerMaker := newParquetColumn("isBuyerMaker", parquetBoolean, parquetNone)
+
+	for _, t := range trades {
+		tradeID.appendInt64(int64(t.TradeID))
+		createdAt.appendInt64(t.CreatedAt)
+		price.appendString(t.Price.String())
+		quantity.appendString(t.Quantity.String())
+		quoteQuantity.appendString(t.QuoteQuantity.String())
+		isBuyerMaker.appendBool(t.IsBuyerMaker)
+	}
+
+	return writeParquet(w, []*parquetColumn{tradeID, createdAt, price, quantity,
+		quoteQuantity, isBuyerMaker}, len(trades))
+}
+
+// WriteDepthParquet writes the order book as a parquet file with DepthColumns
+func WriteDepthParquet(w io.Writer, depths ...Depth) error {
+	ts := newParquetColumn("ts", parquetInt64, parquetTimestampMillis)
+	lastEventID := newParquetColumn("lastEventId", parquetInt64, parquetNone)
+	side := newParquetColumn("side", parquetByteArray, parquetUTF8)
+	level := newParquetColumn("level", parquetInt32, parquetNone)
+	price := newParquetColumn("price", parquetByteArray, parquetUTF8)
+	amount := newParquetColumn("amount", parquetByteArray, parquetUTF8)
+
+	rows := 0
+	for _, d := range depths {
+		forEachLevel(d, func(s string, l int, r DepthRecord) {
+			ts.appendInt64(d.Ts)
+			lastEventID.appendInt64(d.LastEventID)
+			side.appendString(s)
+			level.appendInt32(int32(l))
+			price.appendString(r.Price.String())
+			amount.appendString(r.Amount.String())
+			rows++
+		})
+	}
+
+	return writeParquet(w, []*parquetColumn{ts, lastEventID, side, level,
+		price, amount}, rows)
+}
+
+// forEachLevel calls fn for every bid, then every ask, from the best price.
+// Asks are in descending order in Depth, so they are walked backwards
+func forEachLevel(d Depth, fn func(side string, level int, r DepthRecord)) {
+	for i, r := range d.Bids {
+		fn("bid", i, r)
+	}
+
+	for i := range d.Asks {
+		fn("ask", i, d.Asks[len(d.Asks)-1-i])
+	}
+}
@@ -0,0 +1,161 @@
+package namebase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteDepthCSV(t *testing.T) {
+	d := Depth{
+		Ts:          1585000000000,
+		LastEventID: 9,
+		Bids:        []DepthRecord{{decimal.RequireFromString("0.00000900"), decimal.New(1, 0)}},
+		Asks: []DepthRecord{
+			{decimal.RequireFromString("0.0000012"), decimal.New(1, 0)},
+			{decimal.RequireFromString("0.0000011"), decimal.New(2, 0)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDepthCSV(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ts,lastEventId,side,level,price,amount\n" +
+		"1585000000000,9,bid,0,0.000009,1\n" +
+		"1585000000000,9,ask,0,0.0000011,2\n" +
+		"1585000000000,9,ask,1,0.0000012,1\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+}
+
+func TestWriteTradesParquet(t *testing.T) {
+	trades := []Trade{
+		{TradeID: 1, Price: decimal.New(9, -6), Quantity: decimal.New(10, 0), IsBuyerMaker: true},
+		{TradeID: 2, Price: decimal.New(8, -6), Quantity: decimal.New(20, 0)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTradesParquet(&buf, trades); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte(parquetMagic)) || !bytes.HasSuffix(data, []byte(parquetMagic)) {
+		t.Fatal("missing parquet magic")
+	}
+
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footer <= 0 || footer > len(data)-12 {
+		t.Errorf("invalid footer length: %d", footer)
+	}
+
+	meta := data[len(data)-8-footer : len(data)-8]
+	for _, column := range TradeColumns {
+		if !bytes.Contains(meta, []byte(column)) {
+			t.Errorf("column %s is missing from metadata", column)
+		}
+	}
+}
+
+func TestTradesParquetRows(t *testing.T) {
+	var trades []Trade
+	for i := 0; i < 11; i++ {
+		trades = append(trades, Trade{
+			TradeID:       100 + i,
+			Price:         decimal.New(int64(900+i), -8),
+			Quantity:      decimal.New(int64(10*i+1), -1),
+			QuoteQuantity: decimal.New(int64(900+i)*int64(10*i+1), -9),
+			CreatedAt:     1585000000000 + int64(i)*1000,
+			IsBuyerMaker:  i%3 == 0,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTradesParquet(&buf, trades); err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(columns) != len(TradeColumns) {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+
+	for i, expected := range trades {
+		got := Trade{
+			TradeID:       int(columns["tradeId"][i].(int64)),
+			CreatedAt:     columns["createdAt"][i].(int64),
+			Price:         decimal.RequireFromString(columns["price"][i].(string)),
+			Quantity:      decimal.RequireFromString(columns["quantity"][i].(string)),
+			QuoteQuantity: decimal.RequireFromString(columns["quoteQuantity"][i].(string)),
+			IsBuyerMaker:  columns["isBuyerMaker"][i].(bool),
+		}
+
+		if got.TradeID != expected.TradeID || got.CreatedAt != expected.CreatedAt ||
+			!got.Price.Equal(expected.Price) || !got.Quantity.Equal(expected.Quantity) ||
+			!got.QuoteQuantity.Equal(expected.QuoteQuantity) || got.IsBuyerMaker != expected.IsBuyerMaker {
+			t.Errorf("row %d: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestKlinesParquetRows(t *testing.T) {
+	klines := []Kline{
+		{OpenTime: 1585000000000, CloseTime: 1585003599999,
+			OpenPrice: decimal.New(800, -8), HighPrice: decimal.New(900, -8),
+			LowPrice: decimal.New(700, -8), ClosePrice: decimal.New(850, -8),
+			Volume: decimal.New(10005, -1), QuoteVolume: decimal.New(8, -3), NumberOfTrades: 3},
+		{OpenTime: 1585003600000, CloseTime: 1585007199999,
+			OpenPrice: decimal.New(850, -8), HighPrice: decimal.New(850, -8),
+			LowPrice: decimal.New(850, -8), ClosePrice: decimal.New(850, -8),
+			Volume: decimal.Zero, QuoteVolume: decimal.Zero, NumberOfTrades: 0},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKlinesParquet(&buf, klines); err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(columns) != len(KlineColumns) {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+
+	dec := func(column string, i int) decimal.Decimal {
+		return decimal.RequireFromString(columns[column][i].(string))
+	}
+
+	for i, expected := range klines {
+		got := Kline{
+			OpenTime:       columns["openTime"][i].(int64),
+			CloseTime:      columns["closeTime"][i].(int64),
+			OpenPrice:      dec("openPrice", i),
+			HighPrice:      dec("highPrice", i),
+			LowPrice:       dec("lowPrice", i),
+			ClosePrice:     dec("closePrice", i),
+			Volume:         dec("volume", i),
+			QuoteVolume:    dec("quoteVolume", i),
+			NumberOfTrades: int(columns["numberOfTrades"][i].(int64)),
+		}
+
+		if got.OpenTime != expected.OpenTime || got.CloseTime != expected.CloseTime ||
+			!got.OpenPrice.Equal(expected.OpenPrice) || !got.HighPrice.Equal(expected.HighPrice) ||
+			!got.LowPrice.Equal(expected.LowPrice) || !got.ClosePrice.Equal(expected.ClosePrice) ||
+			!got.Volume.Equal(expected.Volume) || !got.QuoteVolume.Equal(expected.QuoteVolume) ||
+			got.NumberOfTrades != expected.NumberOfTrades {
+			t.Errorf("row %d: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package namebase
+
+import (
+	"flag"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+const restFixture = "testdata/rest.json"
+
+var recordFixtures = flag.Bool("record", false, "record "+restFixture+" from a namebasetest.Server")
+
+// fixtureClient returns a client replaying restFixture, or recording it
+// from a fresh fake exchange with -record. The returned func checks
+// every recorded request has been sent, or saves the recording
+func fixtureClient(t *testing.T) (*Namebase, func()) {
+	if !*recordFixtures {
+		rt, err := namebasetest.NewReplayer(restFixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := NewClient("key", "secret",
+			WithHTTPClient(&http.Client{Transport: rt}), WithRateLimit(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return client, func() {
+			for _, in := range rt.Unserved() {
+				t.Errorf("unserved fixture: %s %s %s", in.Method, in.URL, in.Body)
+			}
+		}
+	}
+
+	s := namebasetest.NewServer()
+	s.SetDepth("HNSBTC", namebasetest.Depth{
+		LastEventID: 42,
+		Bids:        []namebasetest.Level{{"0.00000980", "500"}},
+		Asks:        []namebasetest.Level{{"0.00001010", "300"}, {"0.00001020", "900"}},
+	})
+	s.SetPrice("HNSBTC", "0.00001")
+	s.SetAccount(10, 20,
+		namebasetest.Balance{Asset: "HNS", Unlocked: "1000", LockedInOrders: "0", CanDeposit: true, CanWithdraw: true},
+		namebasetest.Balance{Asset: "BTC", Unlocked: "0.5", LockedInOrders: "0", CanDeposit: true, CanWithdraw: true})
+	s.SetKlines("HNSBTC", "1h", []namebasetest.Kline{
+		{OpenTime: 0, CloseTime: 3599999, OpenPrice: "0.00001", HighPrice: "0.000011",
+			LowPrice: "0.0000095", ClosePrice: "0.0000105", Volume: "1000", QuoteVolume: "0.0102", NumberOfTrades: 12},
+		{OpenTime: 3600000, CloseTime: 7199999, OpenPrice: "0.0000105", HighPrice: "0.000011",
+			LowPrice: "0.00001", ClosePrice: "0.00001", Volume: "500", QuoteVolume: "0.0052", NumberOfTrades: 5},
+	})
+	s.SetDepositAddress("hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw")
+	s.SetWithdrawalFee("0.2")
+	s.AddWithdrawal(namebasetest.Withdrawal{Asset: "HNS", Amount: "40", MinerFee: "0.2",
+		Address: "hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw", TxHash: "txw1", Status: "COMPLETED", CreatedAt: 1500})
+	s.AddDeposit(namebasetest.Deposit{Asset: "BTC", Amount: "0.1", TxHash: "txd1", Status: "COMPLETED", CreatedAt: 1000})
+	s.AddDeposit(namebasetest.Deposit{Asset: "HNS", Amount: "250", TxHash: "txd2", Status: "COMPLETED", CreatedAt: 2000})
+
+	rec := namebasetest.NewRecorder(restFixture, nil)
+	client, err := NewClient("key", "secret", WithBaseURL(s.URL),
+		WithHTTPClient(&http.Client{Transport: rec}), WithRateLimit(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() {
+		s.Close()
+		if err := rec.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestFixtures calls every REST method against recorded responses,
+// run it with -record after changing the requests the client sends
+func TestFixtures(t *testing.T) {
+	client, done := fixtureClient(t)
+	defer done()
+
+	pair := NewCurrencyPair("hns", "btc")
+
+	if d, err := client.GetDepth(pair, 5); err != nil {
+		t.Error(err)
+	} else if d.LastEventID != 42 || len(d.Asks) != 2 || d.Bids[0].Price.String() != "0.0000098" {
+		t.Errorf("unexpected depth: %+v", d)
+	}
+
+	if p, err := client.GetPrice(pair); err != nil {
+		t.Error(err)
+	} else if p.String() != "0.00001" {
+		t.Errorf("unexpected price: %s", p)
+	}
+
+	acct, err := client.GetAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.MakerFee != 10 || acct.TakerFee != 20 || len(acct.Balances) != 2 {
+		t.Errorf("unexpected account: %+v", acct)
+	}
+
+	// 0.5 + 1000 * 0.00001
+	if v, err := client.AccountValue(acct, "BTC"); err != nil {
+		t.Error(err)
+	} else if v.String() != "0.51" {
+		t.Errorf("unexpected value: %s", v)
+	}
+
+	if klines, err := client.GetKlines(pair, KlineInterval1H, 2); err != nil {
+		t.Error(err)
+	} else if len(klines) != 2 || klines[1].ClosePrice.String() != "0.00001" {
+		t.Errorf("unexpected klines: %+v", klines)
+	}
+
+	if klines, err := client.GetKlinesRange(pair, KlineInterval1H,
+		time.Unix(0, 0), time.Unix(7200, 0)); err != nil {
+		t.Error(err)
+	} else if len(klines) != 2 || klines[0].NumberOfTrades != 12 {
+		t.Errorf("unexpected klines: %+v", klines)
+	}
+
+	buy, err := client.LimitBuy(decimal.New(100, 0), decimal.RequireFromString("0.0000095"), pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buy.Side != BuyOrder || buy.Status != OrderStatusNew || buy.Price.String() != "0.0000095" {
+		t.Errorf("unexpected buy: %+v", buy)
+	}
+
+	if o, err := client.LimitSell(decimal.New(50, 0), decimal.RequireFromString("0.000011"), pair); err != nil {
+		t.Error(err)
+	} else if o.Side != SellOrder || o.OriginalQuantity.String() != "50" {
+		t.Errorf("unexpected sell: %+v", o)
+	}
+
+	if o, err := client.MarketBuy(decimal.New(10, 0), pair); err != nil {
+		t.Error(err)
+	} else if o.Status != OrderStatusFilled || o.Type != OrderTypeMarket {
+		t.Errorf("unexpected market buy: %+v", o)
+	}
+
+	if o, err := client.MarketSell(decimal.New(10, 0), pair); err != nil {
+		t.Error(err)
+	} else if o.Status != OrderStatusFilled || o.Side != SellOrder {
+		t.Errorf("unexpected market sell: %+v", o)
+	}
+
+	if orders, err := client.OpenOrders(pair); err != nil {
+		t.Error(err)
+	} else if len(orders) != 2 {
+		t.Errorf("unexpected open orders: %+v", orders)
+	}
+
+	if o, err := client.GetOrder(buy.OrderID, pair); err != nil {
+		t.Error(err)
+	} else if o.OrderID != buy.OrderID || !o.IsOpen() {
+		t.Errorf("unexpected order: %+v", o)
+	}
+
+	if ok, err := client.CancelOrder(buy.OrderID, pair); err != nil || !ok {
+		t.Errorf("cancel: %v, %v", ok, err)
+	}
+
+	if addr, err := client.DepositAddr("HNS"); err != nil {
+		t.Error(err)
+	} else if addr.Address != "hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw" || addr.Network != "HNS" {
+		t.Errorf("unexpected address: %+v", addr)
+	}
+
+	w, err := client.Withdraw("HNS", decimal.New(100, 0),
+		"hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.ID == "" || w.Fee.String() != "0.2" {
+		t.Errorf("unexpected withdrawal: %+v", w)
+	}
+
+	if history, err := client.WithdrawalHistory("HNS", time.Unix(0, 0), time.Unix(10, 0)); err != nil {
+		t.Error(err)
+	} else if len(history) != 1 || history[0].TxHash != "txw1" {
+		t.Errorf("unexpected withdrawals: %+v", history)
+	}
+
+	if history, err := client.DepositHistory("", time.Unix(0, 0), time.Unix(10, 0)); err != nil {
+		t.Error(err)
+	} else if len(history) != 2 || history[0].Asset != "BTC" || history[1].Amount.String() != "250" {
+		t.Errorf("unexpected deposits: %+v", history)
+	}
+}
@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/gorilla/websocket v1.4.1
 	github.com/shopspring/decimal v0.0.0-20200227202807-02e2044944cc
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 )
@@ -0,0 +1,190 @@
+package namebase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrWithdrawalDenied is returned, wrapped with the reason,
+// when a WithdrawalPolicy refuses a withdrawal
+var ErrWithdrawalDenied = errors.New("withdrawal denied")
+
+// WithdrawalRequest is a withdrawal checked by a WithdrawalPolicy
+type WithdrawalRequest struct {
+	Asset   Currency
+	Amount  decimal.Decimal
+	Address string
+	Memo    string
+}
+
+// WithdrawalPolicy restricts what Withdraw sends out of the exchange.
+// Assets are case insensitive, addresses are compared as given
+type WithdrawalPolicy struct {
+	// Allowlist maps assets to the addresses they may be withdrawn to,
+	// assets missing from it cannot be withdrawn. A nil Allowlist allows any address
+	Allowlist map[Currency][]string
+	// MaxAmount caps a single withdrawal of an asset
+	MaxAmount map[Currency]decimal.Decimal
+	// DailyLimit caps the total withdrawn of an asset in the last 24 hours
+	DailyLimit map[Currency]decimal.Decimal
+	// Confirm, if set, is called last, a withdrawal is only submitted if it returns true
+	Confirm func(WithdrawalRequest) bool
+	// DryRun checks withdrawals and logs them at info level without submitting them
+	DryRun bool
+}
+
+// WithWithdrawalPolicy checks every withdrawal of the client against p
+func WithWithdrawalPolicy(p WithdrawalPolicy) ClientOption {
+	return func(nb *Namebase) {
+		nb.guard = &withdrawalGuard{policy: p.normalize(), now: time.Now}
+	}
+}
+
+// normalize returns a copy of p with the assets in the form of NewCurrency,
+// assets given twice get the addresses of both and the smaller limits
+func (p WithdrawalPolicy) normalize() WithdrawalPolicy {
+	if p.Allowlist != nil {
+		allowlist := make(map[Currency][]string, len(p.Allowlist))
+		for asset, addrs := range p.Allowlist {
+			asset = NewCurrency(string(asset))
+			allowlist[asset] = append(allowlist[asset], addrs...)
+		}
+		p.Allowlist = allowlist
+	}
+
+	p.MaxAmount = normalizeLimits(p.MaxAmount)
+	p.DailyLimit = normalizeLimits(p.DailyLimit)
+
+	return p
+}
+
+func normalizeLimits(limits map[Currency]decimal.Decimal) map[Currency]decimal.Decimal {
+	if limits == nil {
+		return nil
+	}
+
+	normalized := make(map[Currency]decimal.Decimal, len(limits))
+	for asset, limit := range limits {
+		asset = NewCurrency(string(asset))
+		if prev, ok := normalized[asset]; ok && prev.LessThan(limit) {
+			continue
+		}
+		normalized[asset] = limit
+	}
+
+	return normalized
+}
+
+type withdrawal struct {
+	asset  Currency
+	amount decimal.Decimal
+	at     time.Time
+}
+
+// withdrawalGuard enforces a WithdrawalPolicy,
+// a nil guard allows every withdrawal
+type withdrawalGuard struct {
+	policy WithdrawalPolicy
+	now    func() time.Time
+
+	mu      sync.Mutex
+	history []*withdrawal
+}
+
+// reserve checks the request and counts it towards the daily limit,
+// it must be released if the withdrawal is not made
+func (g *withdrawalGuard) reserve(req WithdrawalRequest) (*withdrawal, error) {
+	if g == nil {
+		return nil, nil
+	}
+
+	p := g.policy
+	req.Asset = NewCurrency(string(req.Asset))
+
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount %s is not positive", ErrWithdrawalDenied, req.Amount)
+	}
+
+	if p.Allowlist != nil && !allowed(p.Allowlist[req.Asset], req.Address) {
+		return nil, fmt.Errorf("%w: %s is not allowed for %s", ErrWithdrawalDenied, req.Address, req.Asset)
+	}
+
+	if max, ok := p.MaxAmount[req.Asset]; ok && req.Amount.GreaterThan(max) {
+		return nil, fmt.Errorf("%w: %s %s exceeds the limit of %s per withdrawal",
+			ErrWithdrawalDenied, req.Amount, req.Asset, max)
+	}
+
+	g.mu.Lock()
+	now := g.now()
+	since := now.Add(-24 * time.Hour)
+
+	kept := g.history[:0]
+	total := req.Amount
+	for _, w := range g.history {
+		if w.at.Before(since) {
+			continue
+		}
+		kept = append(kept, w)
+		if w.asset == req.Asset {
+			total = total.Add(w.amount)
+		}
+	}
+	g.history = kept
+
+	if limit, ok := p.DailyLimit[req.Asset]; ok && total.GreaterThan(limit) {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s exceeds the daily limit of %s",
+			ErrWithdrawalDenied, total, req.Asset, limit)
+	}
+
+	w := &withdrawal{asset: req.Asset, amount: req.Amount, at: now}
+	g.history = append(g.history, w)
+	g.mu.Unlock()
+
+	if p.Confirm != nil && !p.Confirm(req) {
+		g.release(w)
+		return nil, fmt.Errorf("%w: not confirmed", ErrWithdrawalDenied)
+	}
+
+	if p.DryRun {
+		g.release(w)
+	}
+
+	return w, nil
+}
+
+// release no longer counts a withdrawal which has not been made
+func (g *withdrawalGuard) release(w *withdrawal) {
+	if g == nil || w == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, h := range g.history {
+		if h == w {
+			g.history = append(g.history[:i], g.history[i+1:]...)
+			return
+		}
+	}
+}
+
+// dryRun reports whether withdrawals are only checked
+func (g *withdrawalGuard) dryRun() bool {
+	return g != nil && g.policy.DryRun
+}
+
+func allowed(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package namebase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWithdrawalPolicy(t *testing.T) {
+	other := "hs1qz5tpwxqergd3c8g7ruszzg3rysjjvfegcdu6yc"
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var confirmed []WithdrawalRequest
+	client := *nb
+	WithWithdrawalPolicy(WithdrawalPolicy{
+		Allowlist:  map[Currency][]string{"HNS": {testAddress}},
+		MaxAmount:  map[Currency]decimal.Decimal{"HNS": decimal.New(100, 0)},
+		DailyLimit: map[Currency]decimal.Decimal{"HNS": decimal.New(150, 0)},
+		Confirm: func(req WithdrawalRequest) bool {
+			confirmed = append(confirmed, req)
+			return req.Memo != "no"
+		},
+	})(&client)
+	client.guard.now = func() time.Time { return now }
+
+	withdraw := func(asset Currency, amount int64, address, memo string) error {
+		_, err := client.Withdraw(asset, decimal.New(amount, 0), address, memo)
+		return err
+	}
+
+	denied := []struct {
+		name string
+		err  error
+	}{
+		{"other address", withdraw("HNS", 10, other, "")},
+		{"unlisted asset", withdraw("BTC", 1, testAddress, "")},
+		{"per withdrawal cap", withdraw("HNS", 101, testAddress, "")},
+		{"not confirmed", withdraw("HNS", 10, testAddress, "no")},
+	}
+	for _, d := range denied {
+		if !errors.Is(d.err, ErrWithdrawalDenied) {
+			t.Errorf("%s: expected denial, got %v", d.name, d.err)
+		}
+	}
+
+	if err := withdraw("HNS", 100, testAddress, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// the unconfirmed withdrawal does not count towards the daily limit
+	if err := withdraw("HNS", 60, testAddress, ""); !errors.Is(err, ErrWithdrawalDenied) {
+		t.Errorf("expected daily limit, got %v", err)
+	}
+	if err := withdraw("HNS", 50, testAddress, ""); err != nil {
+		t.Error(err)
+	}
+
+	now = now.Add(24*time.Hour + time.Second)
+	if err := withdraw("HNS", 100, testAddress, ""); err != nil {
+		t.Errorf("daily limit is not reset: %v", err)
+	}
+
+	if len(confirmed) != 4 {
+		t.Errorf("unexpected confirmations: %+v", confirmed)
+	}
+}
+
+func TestWithdrawalDryRun(t *testing.T) {
+	client := *nb
+	WithWithdrawalPolicy(WithdrawalPolicy{DryRun: true})(&client)
+
+	before := len(srv.Requests())
+	if w, err := client.Withdraw("HNS", decimal.New(10, 0), testAddress, ""); err != nil {
+		t.Fatal(err)
+	} else if w.ID != "" || w.Amount.String() != "10" {
+		t.Errorf("unexpected dry run: %+v", w)
+	}
+
+	if len(srv.Requests()) != before {
+		t.Error("dry run has submitted the withdrawal")
+	}
+}
+
+func TestWithdrawalPolicyAssetCase(t *testing.T) {
+	client := *nb
+	WithLogger(NopLogger{})(&client)
+	WithWithdrawalPolicy(WithdrawalPolicy{
+		Allowlist:  map[Currency][]string{"hns": {testAddress}},
+		MaxAmount:  map[Currency]decimal.Decimal{"hns": decimal.New(200, 0)},
+		DailyLimit: map[Currency]decimal.Decimal{"Hns": decimal.New(150, 0), "HNS": decimal.New(500, 0)},
+		DryRun:     true,
+	})(&client)
+
+	if _, err := client.Withdraw("HNS", decimal.New(201, 0), testAddress, ""); !errors.Is(err, ErrWithdrawalDenied) {
+		t.Errorf("lowercase per withdrawal cap is ignored: %v", err)
+	}
+
+	if _, err := client.Withdraw("HNS", decimal.New(100, 0), testAddress, ""); err != nil {
+		t.Errorf("lowercase allowlist is ignored: %v", err)
+	}
+
+	// the smaller of the daily limits given for HNS applies
+	if _, err := client.Withdraw("hns", decimal.New(160, 0), testAddress, ""); !errors.Is(err, ErrWithdrawalDenied) {
+		t.Errorf("lowercase daily limit is ignored: %v", err)
+	}
+}
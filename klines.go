@@ -0,0 +1,109 @@
+package namebase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// klinesPageLimit is the max number of klines returned by one request
+const klinesPageLimit = 1000
+
+// GetKlinesRange returns the klines of pair opened between start and end,
+// paging through the API as many times as needed. The klines are sorted by
+// open time without duplicates, and intervals without trades between the first
+// and the last kline are filled with empty klines priced at the previous close.
+func (nb *Namebase) GetKlinesRange(pair CurrencyPair, interval KlineInterval,
+	start, end time.Time) ([]Kline, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return nil, fmt.Errorf("unsupported kline interval: %s", interval)
+	}
+
+	if end.Before(start) {
+		return nil, fmt.Errorf("end %s is before start %s", end, start)
+	}
+
+	stepMs := int64(step / time.Millisecond)
+	endMs := timeToMs(end)
+	cursor := timeToMs(start)
+	seen := make(map[int64]Kline)
+
+	for cursor <= endMs {
+		params := make(map[string]interface{})
+		params["symbol"] = pair.String()
+		params["interval"] = interval
+		params["startTime"] = cursor
+		params["endTime"] = endMs
+		params["limit"] = klinesPageLimit
+
+		page, err := nb.getKlines(params)
+		if err != nil {
+			return nil, err
+		}
+
+		next := cursor
+		for _, k := range page {
+			if k.OpenTime < cursor || k.OpenTime > endMs {
+				continue
+			}
+			seen[k.OpenTime] = k
+			if k.OpenTime >= next {
+				next = k.OpenTime + stepMs
+			}
+		}
+
+		// no progress means there is nothing left in the range
+		if next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	klines := make([]Kline, 0, len(seen))
+	for _, k := range seen {
+		klines = append(klines, k)
+	}
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].OpenTime < klines[j].OpenTime
+	})
+
+	return fillKlineGaps(klines, step), nil
+}
+
+// fillKlineGaps inserts empty klines between klines which are more
+// than one interval apart. klines must be sorted by open time
+func fillKlineGaps(klines []Kline, step time.Duration) []Kline {
+	if len(klines) < 2 {
+		return klines
+	}
+
+	stepMs := int64(step / time.Millisecond)
+	filled := make([]Kline, 0, len(klines))
+	for i, k := range klines {
+		if i > 0 {
+			prev := filled[len(filled)-1]
+			for t := prev.OpenTime + stepMs; t < k.OpenTime; t += stepMs {
+				filled = append(filled, emptyKline(t, stepMs, prev.ClosePrice))
+			}
+		}
+		filled = append(filled, k)
+	}
+
+	return filled
+}
+
+func emptyKline(openTime, stepMs int64, price decimal.Decimal) Kline {
+	return Kline{
+		OpenTime:    openTime,
+		CloseTime:   openTime + stepMs - 1,
+		OpenPrice:   price,
+		HighPrice:   price,
+		LowPrice:    price,
+		ClosePrice:  price,
+		Volume:      decimal.Zero,
+		QuoteVolume: decimal.Zero,
+	}
+}
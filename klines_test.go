@@ -0,0 +1,123 @@
+package namebase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+func TestFillKlineGaps(t *testing.T) {
+	minute := int64(time.Minute / time.Millisecond)
+	klines := []Kline{
+		{OpenTime: 0, CloseTime: minute - 1, ClosePrice: decimal.NewFromFloat(1.5)},
+		{OpenTime: 3 * minute, CloseTime: 4*minute - 1, ClosePrice: decimal.NewFromFloat(2)},
+	}
+
+	filled := fillKlineGaps(klines, time.Minute)
+	if len(filled) != 4 {
+		t.Fatalf("expected 4 klines, got %d", len(filled))
+	}
+
+	for i, k := range filled {
+		if k.OpenTime != int64(i)*minute {
+			t.Errorf("kline %d opens at %d", i, k.OpenTime)
+		}
+	}
+
+	if gap := filled[1]; !gap.OpenPrice.Equal(decimal.NewFromFloat(1.5)) || !gap.Volume.IsZero() {
+		t.Errorf("unexpected empty kline: %+v", gap)
+	}
+}
+
+func TestResampleKlines(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	var hourly []Kline
+	for i := int64(0); i < 8; i++ {
+		price := decimal.New(10+i, 0)
+		hourly = append(hourly, Kline{
+			OpenTime:       i * hour,
+			CloseTime:      (i+1)*hour - 1,
+			OpenPrice:      price,
+			HighPrice:      price.Add(decimal.New(1, 0)),
+			LowPrice:       price.Sub(decimal.New(1, 0)),
+			ClosePrice:     price,
+			Volume:         decimal.New(1, 0),
+			QuoteVolume:    price,
+			NumberOfTrades: 2,
+		})
+	}
+
+	bars, err := ResampleKlines(hourly, KlineInterval4H)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bars) != 2 {
+		t.Fatalf("expected 2 klines, got %d", len(bars))
+	}
+
+	b := bars[1]
+	if b.OpenTime != 4*hour || b.CloseTime != 8*hour-1 {
+		t.Errorf("unexpected boundaries: %d - %d", b.OpenTime, b.CloseTime)
+	}
+
+	if b.OpenPrice.String() != "14" || b.ClosePrice.String() != "17" ||
+		b.HighPrice.String() != "18" || b.LowPrice.String() != "13" {
+		t.Errorf("unexpected ohlc: %+v", b)
+	}
+
+	if b.Volume.String() != "4" || b.QuoteVolume.String() != "62" || b.NumberOfTrades != 8 {
+		t.Errorf("unexpected volumes: %+v", b)
+	}
+
+	if _, err := ResampleKlines(hourly, KlineInterval15Min); err == nil {
+		t.Error("expected error when resampling into a smaller interval")
+	}
+}
+
+func TestKlineIntervalAlign(t *testing.T) {
+	ts := time.Date(2020, 3, 18, 13, 45, 0, 0, time.UTC)
+
+	cases := map[KlineInterval]time.Time{
+		KlineInterval6H:     time.Date(2020, 3, 18, 12, 0, 0, 0, time.UTC),
+		KlineInterval1Week:  time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC),
+		KlineInterval1Month: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for interval, expected := range cases {
+		if aligned := interval.Align(ts); !aligned.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s", interval, expected, aligned)
+		}
+	}
+}
+
+func TestGetKlinesRange(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	var klines []namebasetest.Kline
+	for i := int64(0); i < 2500; i++ {
+		// the 10th hour has no trades
+		if i == 10 {
+			continue
+		}
+		klines = append(klines, namebasetest.Kline{OpenTime: i * hour, CloseTime: (i+1)*hour - 1,
+			OpenPrice: "1", HighPrice: "1", LowPrice: "1", ClosePrice: "1", Volume: "1", QuoteVolume: "1"})
+	}
+	srv.SetKlines("HNSBTC", "1h", klines)
+
+	result, err := nb.GetKlinesRange(NewCurrencyPair("hns", "btc"), KlineInterval1H,
+		msToTime(0), msToTime(2499*hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 2500 {
+		t.Fatalf("expected 2500 klines, got %d", len(result))
+	}
+
+	if gap := result[10]; gap.OpenTime != 10*hour || !gap.Volume.IsZero() {
+		t.Errorf("unexpected gap: %+v", gap)
+	}
+}
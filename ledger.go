@@ -0,0 +1,295 @@
+package namebase
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// basisPoints converts fees in basis points, as in Account.MakerFee, to rates
+var basisPoints = decimal.New(1, -4)
+
+// PaperFill is a simulated execution of an order of PaperTrader or Backtest
+type PaperFill struct {
+	Fill
+	OrderID int
+	Pair    CurrencyPair
+	Side    OrderSide
+	IsMaker bool
+	Time    int64
+}
+
+type paperOrder struct {
+	Order
+	pair CurrencyPair
+	// locked is what is left of the balance locked when the order was placed,
+	// in quote currency for buy orders and base currency for sell orders
+	locked decimal.Decimal
+	// activeAt is when the order reaches the simulated exchange, in milliseconds
+	activeAt int64
+}
+
+func (o *paperOrder) copy() *Order {
+	c := o.Order
+	c.Fills = make([]Fill, len(o.Fills))
+	copy(c.Fills, o.Fills)
+
+	return &c
+}
+
+// ledger keeps the balances and orders of a simulated account,
+// it is shared by PaperTrader and Backtest.
+// Fees are charged on the received asset
+type ledger struct {
+	// nb validates orders against the symbol precisions
+	nb       *Namebase
+	now      func() int64
+	makerBps int
+	takerBps int
+	makerFee decimal.Decimal
+	takerFee decimal.Decimal
+	balances map[Currency]*Balance
+	orders   map[int]*paperOrder
+	nextID   int
+}
+
+func newLedger(nb *Namebase, balances map[Currency]decimal.Decimal,
+	makerFee, takerFee int, now func() int64) ledger {
+	l := ledger{
+		nb:       nb,
+		now:      now,
+		makerBps: makerFee,
+		takerBps: takerFee,
+		makerFee: decimal.New(int64(makerFee), 0).Mul(basisPoints),
+		takerFee: decimal.New(int64(takerFee), 0).Mul(basisPoints),
+		balances: make(map[Currency]*Balance),
+		orders:   make(map[int]*paperOrder),
+		nextID:   1,
+	}
+
+	for c, amount := range balances {
+		l.balance(c).Unlocked = amount
+	}
+
+	return l
+}
+
+// newOrder validates the request and locks the balance the order needs,
+// market buy orders spend the quote balance as they are filled instead
+func (l *ledger) newOrder(req OrderRequest) (*paperOrder, error) {
+	if _, err := l.nb.orderParams(req); err != nil {
+		return nil, err
+	}
+
+	info := l.nb.symbolInfo[req.Pair]
+	qty := req.Quantity.Truncate(info.BasePrecision)
+	price := req.Price.Truncate(info.QuotePrecision)
+	if req.Type == OrderTypeMarket {
+		price = decimal.Zero
+	}
+
+	lockAsset, lock := req.Pair.Base, qty
+	if req.Side == BuyOrder {
+		lockAsset, lock = req.Pair.Quote, qty.Mul(price)
+	}
+
+	balance := l.balance(lockAsset)
+	if balance.Unlocked.LessThan(lock) {
+		return nil, fmt.Errorf("insufficient %s balance: %s, required: %s",
+			lockAsset, balance.Unlocked, lock)
+	}
+	balance.Unlocked = balance.Unlocked.Sub(lock)
+	balance.Locked = balance.Locked.Add(lock)
+
+	now := l.now()
+	o := &paperOrder{
+		Order: Order{
+			OrderID:          l.nextID,
+			Price:            price,
+			OriginalQuantity: qty,
+			ExecutedQuantity: decimal.Zero,
+			Status:           OrderStatusNew,
+			Type:             req.Type,
+			Side:             req.Side,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		},
+		pair:     req.Pair,
+		locked:   lock,
+		activeAt: now,
+	}
+	l.nextID++
+	l.orders[o.OrderID] = o
+
+	return o, nil
+}
+
+// fillable returns how much of the order can be filled at price,
+// market buy orders are limited by the quote balance
+func (l *ledger) fillable(o *paperOrder, price decimal.Decimal) decimal.Decimal {
+	qty := o.RemainingQuantity()
+	if o.Type != OrderTypeMarket || o.Side != BuyOrder || !price.IsPositive() {
+		return qty
+	}
+
+	info := l.nb.symbolInfo[o.pair]
+	affordable := l.balance(o.pair.Quote).Unlocked.Div(price).Truncate(info.BasePrecision)
+
+	return decimal.Min(qty, affordable)
+}
+
+// fill executes qty of the order at price and settles the balances
+func (l *ledger) fill(o *paperOrder, price, qty decimal.Decimal, maker bool) PaperFill {
+	rate := l.takerFee
+	if maker {
+		rate = l.makerFee
+	}
+
+	quoteQty := price.Mul(qty)
+	base, quote := l.balance(o.pair.Base), l.balance(o.pair.Quote)
+	f := PaperFill{
+		OrderID: o.OrderID,
+		Pair:    o.pair,
+		Side:    o.Side,
+		IsMaker: maker,
+		Time:    l.now(),
+	}
+	f.Price, f.Quantity, f.QuoteQuantity = price, qty, quoteQty
+
+	if o.Side == BuyOrder {
+		if o.Type == OrderTypeLimit {
+			// the balance was locked at the limit price, the difference is refunded
+			locked := o.Price.Mul(qty)
+			o.locked = o.locked.Sub(locked)
+			quote.Locked = quote.Locked.Sub(locked)
+			quote.Unlocked = quote.Unlocked.Add(locked.Sub(quoteQty))
+		} else {
+			quote.Unlocked = quote.Unlocked.Sub(quoteQty)
+		}
+
+		f.Commission, f.CommissionAsset = qty.Mul(rate), string(o.pair.Base)
+		base.Unlocked = base.Unlocked.Add(qty.Sub(f.Commission))
+	} else {
+		o.locked = o.locked.Sub(qty)
+		base.Locked = base.Locked.Sub(qty)
+
+		f.Commission, f.CommissionAsset = quoteQty.Mul(rate), string(o.pair.Quote)
+		quote.Unlocked = quote.Unlocked.Add(quoteQty.Sub(f.Commission))
+	}
+
+	o.ExecutedQuantity = o.ExecutedQuantity.Add(qty)
+	o.Fills = append(o.Fills, f.Fill)
+	o.UpdatedAt = f.Time
+	if o.RemainingQuantity().IsZero() {
+		o.Status = OrderStatusFilled
+		l.unlock(o)
+	} else {
+		o.Status = OrderStatusPartiallyFilled
+	}
+
+	return f
+}
+
+// expire ends a market order which cannot be filled any further
+func (l *ledger) expire(o *paperOrder) {
+	if o.ExecutedQuantity.IsZero() {
+		o.Status = OrderStatusRejected
+	} else {
+		o.Status = OrderStatusExpired
+	}
+	o.UpdatedAt = l.now()
+	l.unlock(o)
+}
+
+// unlock releases what is left of the balance locked by the order
+func (l *ledger) unlock(o *paperOrder) {
+	asset := o.pair.Base
+	if o.Side == BuyOrder {
+		asset = o.pair.Quote
+	}
+
+	b := l.balance(asset)
+	b.Locked = b.Locked.Sub(o.locked)
+	b.Unlocked = b.Unlocked.Add(o.locked)
+	o.locked = decimal.Zero
+}
+
+func (l *ledger) cancel(orderID int, pair CurrencyPair) error {
+	o, err := l.order(orderID, pair)
+	if err != nil {
+		return err
+	}
+
+	if !o.IsOpen() {
+		return fmt.Errorf("order %d is %s", orderID, o.Status)
+	}
+
+	o.Status = OrderStatusCanceled
+	o.UpdatedAt = l.now()
+	l.unlock(o)
+
+	return nil
+}
+
+func (l *ledger) balance(c Currency) *Balance {
+	c = NewCurrency(string(c))
+	b, ok := l.balances[c]
+	if !ok {
+		b = &Balance{Asset: c, CanDeposit: true, CanWithdraw: true}
+		l.balances[c] = b
+	}
+
+	return b
+}
+
+func (l *ledger) account() *Account {
+	acct := &Account{
+		MakerFee: l.makerBps,
+		TakerFee: l.takerBps,
+		CanTrade: true,
+	}
+
+	for _, b := range l.balances {
+		acct.Balances = append(acct.Balances, *b)
+	}
+	sort.Slice(acct.Balances, func(i, j int) bool {
+		return acct.Balances[i].Asset < acct.Balances[j].Asset
+	})
+
+	return acct
+}
+
+func (l *ledger) order(orderID int, pair CurrencyPair) (*paperOrder, error) {
+	o, ok := l.orders[orderID]
+	if !ok || o.pair != pair {
+		return nil, fmt.Errorf("order %d of %s not found", orderID, pair)
+	}
+
+	return o, nil
+}
+
+// openOrders returns the open orders of pair, oldest first
+func (l *ledger) openOrders(pair CurrencyPair) []*paperOrder {
+	var orders []*paperOrder
+	for _, o := range l.orders {
+		if o.pair == pair && o.IsOpen() {
+			orders = append(orders, o)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].OrderID < orders[j].OrderID
+	})
+
+	return orders
+}
+
+func copyOrders(orders []*paperOrder) []Order {
+	var result []Order
+	for _, o := range orders {
+		result = append(result, *o.copy())
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package namebase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger receives the messages of the client. args are alternating keys and
+// values, e.g. "endpoint", "/ws/v0/ticker/depth", "error", err, the same as
+// the methods of *slog.Logger, which can be passed to WithLogger as it is
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// WithLogger routes the messages of the client to l,
+// by default they are written to the standard logger by a StdLogger
+func WithLogger(l Logger) ClientOption {
+	return func(nb *Namebase) {
+		if l == nil {
+			l = NopLogger{}
+		}
+		nb.logger = l
+	}
+}
+
+// log returns the logger of the client, clients not created by NewClient log nothing
+func (nb *Namebase) log() Logger {
+	if nb.logger == nil {
+		return NopLogger{}
+	}
+
+	return nb.logger
+}
+
+// StdLogger writes messages to a standard library logger, as
+// "[namebase] LEVEL msg key=value ...". Debug messages are dropped unless Verbose
+type StdLogger struct {
+	// Out is the logger to write to, the standard logger if nil
+	Out     *log.Logger
+	Verbose bool
+}
+
+// Debug implements Logger
+func (l *StdLogger) Debug(msg string, args ...interface{}) {
+	if l.Verbose {
+		l.print("DEBUG", msg, args)
+	}
+}
+
+// Info implements Logger
+func (l *StdLogger) Info(msg string, args ...interface{}) {
+	l.print("INFO", msg, args)
+}
+
+// Warn implements Logger
+func (l *StdLogger) Warn(msg string, args ...interface{}) {
+	l.print("WARN", msg, args)
+}
+
+// Error implements Logger
+func (l *StdLogger) Error(msg string, args ...interface{}) {
+	l.print("ERROR", msg, args)
+}
+
+func (l *StdLogger) print(level, msg string, args []interface{}) {
+	var b strings.Builder
+	b.WriteString("[namebase] ")
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		b.WriteByte(' ')
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, "%v=%q", args[i], fmt.Sprint(args[i+1]))
+		} else {
+			fmt.Fprintf(&b, "!BADKEY=%q", fmt.Sprint(args[i]))
+		}
+	}
+
+	if l.Out != nil {
+		l.Out.Print(b.String())
+	} else {
+		log.Print(b.String())
+	}
+}
+
+// NopLogger discards every message
+type NopLogger struct{}
+
+// Debug implements Logger
+func (NopLogger) Debug(msg string, args ...interface{}) {}
+
+// Info implements Logger
+func (NopLogger) Info(msg string, args ...interface{}) {}
+
+// Warn implements Logger
+func (NopLogger) Warn(msg string, args ...interface{}) {}
+
+// Error implements Logger
+func (NopLogger) Error(msg string, args ...interface{}) {}
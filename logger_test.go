@@ -0,0 +1,88 @@
+package namebase
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// testLogger records messages as "LEVEL msg key=value ..."
+type testLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *testLogger) add(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, strings.TrimSpace(fmt.Sprintln(append([]interface{}{level, msg}, args...)...)))
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.add("DEBUG", msg, args) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.add("INFO", msg, args) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.add("WARN", msg, args) }
+func (l *testLogger) Error(msg string, args ...interface{}) { l.add("ERROR", msg, args) }
+
+func (l *testLogger) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.msgs...)
+}
+
+func (l *testLogger) find(prefix string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, m := range l.msgs {
+		if strings.HasPrefix(m, prefix) {
+			return m
+		}
+	}
+	return ""
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := &StdLogger{Out: log.New(&buf, "", 0)}
+
+	l.Debug("dropped")
+	l.Warn("reconnecting", "endpoint", "/ws", "pair", NewCurrencyPair("hns", "btc"), "odd")
+
+	if got := buf.String(); got != "[namebase] WARN reconnecting endpoint=\"/ws\" pair=\"HNSBTC\" !BADKEY=\"odd\"\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestLoggerFields(t *testing.T) {
+	logger := &testLogger{}
+	s, client := newTestServer(t, WithLogger(logger),
+		WithWithdrawalPolicy(WithdrawalPolicy{DryRun: true}))
+	defer s.Close()
+
+	if _, err := client.Withdraw("HNS", decimal.New(1, 0), testAddress, ""); err != nil {
+		t.Fatal(err)
+	}
+	if m := logger.find("INFO withdrawal dry run endpoint /api/v0/withdraw asset HNS"); m == "" {
+		t.Errorf("dry run is not logged: %q", logger.all())
+	}
+
+	if _, err := client.SubTrades(NewCurrencyPair("hns", "btc")); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, tradesStreamPath)
+	s.DropConnections()
+
+	for i := 0; i < 100 && logger.find("WARN") == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if m := logger.find("WARN failed to read from websocket, reconnecting endpoint " +
+		tradesStreamPath + " pair HNSBTC error"); m == "" {
+		t.Errorf("reconnect is not logged: %q", logger.all())
+	}
+}
@@ -0,0 +1,306 @@
+package namebase
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error types of Metrics.ObserveRequest
+const (
+	ErrTypeTimeout = "timeout"
+	ErrTypeNetwork = "network"
+	ErrTypeHTTP    = "http"
+	ErrTypeAPI     = "api"
+	ErrTypeRead    = "read"
+)
+
+// Metrics receives measurements of the client, its methods are called
+// from the goroutines of requests and streams and must not block
+type Metrics interface {
+	// ObserveRequest is called after every REST request, errType is empty
+	// on success, otherwise one of the ErrType constants
+	ObserveRequest(endpoint string, latency time.Duration, errType string)
+	// ObserveLimiterWait is called with the time every request has waited for the rate limiter
+	ObserveLimiterWait(wait time.Duration)
+	// StreamReconnected is called when a websocket has been reconnected
+	StreamReconnected(endpoint string)
+	// StreamMessage is called with every websocket message
+	StreamMessage(endpoint string)
+	// DepthResync is called when the order book of SubDepth is reset
+	// from a snapshot, on subscription and after every reconnection
+	DepthResync(pair CurrencyPair)
+	// DepthUpdated is called when the order book of SubDepth is updated
+	DepthUpdated(pair CurrencyPair, at time.Time)
+}
+
+// WithMetrics reports the measurements of the client to m,
+// e.g. a PrometheusMetrics
+func WithMetrics(m Metrics) ClientOption {
+	return func(nb *Namebase) {
+		nb.metrics = m
+	}
+}
+
+// observe returns the metrics of the client, which discard everything if not set
+func (nb *Namebase) observe() Metrics {
+	if nb.metrics == nil {
+		return nopMetrics{}
+	}
+
+	return nb.metrics
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, time.Duration, string) {}
+func (nopMetrics) ObserveLimiterWait(time.Duration)             {}
+func (nopMetrics) StreamReconnected(string)                     {}
+func (nopMetrics) StreamMessage(string)                         {}
+func (nopMetrics) DepthResync(CurrencyPair)                     {}
+func (nopMetrics) DepthUpdated(CurrencyPair, time.Time)         {}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the request latency histogram
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics keeps the measurements of clients and exposes them in the
+// Prometheus text format, on its own or through a /metrics handler:
+//
+//	m := namebase.NewPrometheusMetrics()
+//	nb, err := namebase.NewClient(key, secret, namebase.WithMetrics(m))
+//	http.Handle("/metrics", m)
+type PrometheusMetrics struct {
+	buckets []float64
+	now     func() time.Time
+
+	mu          sync.Mutex
+	requests    map[string]*histogram
+	errors      map[[2]string]uint64
+	waits       uint64
+	waitSeconds float64
+	reconnects  map[string]uint64
+	messages    map[string]uint64
+	resyncs     map[CurrencyPair]uint64
+	updated     map[CurrencyPair]time.Time
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics creates a PrometheusMetrics, with DefaultLatencyBuckets
+// unless other bucket bounds are given
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:    buckets,
+		now:        time.Now,
+		requests:   make(map[string]*histogram),
+		errors:     make(map[[2]string]uint64),
+		reconnects: make(map[string]uint64),
+		messages:   make(map[string]uint64),
+		resyncs:    make(map[CurrencyPair]uint64),
+		updated:    make(map[CurrencyPair]time.Time),
+	}
+}
+
+// ObserveRequest implements Metrics
+func (m *PrometheusMetrics) ObserveRequest(endpoint string, latency time.Duration, errType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.requests[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.requests[endpoint] = h
+	}
+
+	s := latency.Seconds()
+	for i, le := range m.buckets {
+		if s <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += s
+	h.count++
+
+	if errType != "" {
+		m.errors[[2]string{endpoint, errType}]++
+	}
+}
+
+// ObserveLimiterWait implements Metrics
+func (m *PrometheusMetrics) ObserveLimiterWait(wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.waits++
+	m.waitSeconds += wait.Seconds()
+}
+
+// StreamReconnected implements Metrics
+func (m *PrometheusMetrics) StreamReconnected(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects[endpoint]++
+}
+
+// StreamMessage implements Metrics
+func (m *PrometheusMetrics) StreamMessage(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[endpoint]++
+}
+
+// DepthResync implements Metrics
+func (m *PrometheusMetrics) DepthResync(pair CurrencyPair) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resyncs[pair]++
+}
+
+// DepthUpdated implements Metrics
+func (m *PrometheusMetrics) DepthUpdated(pair CurrencyPair, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updated[pair] = at
+}
+
+// ServeHTTP serves the metrics to a Prometheus scrape
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format. The staleness
+// of order books is the time since their last update at the time of writing
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	now := m.now()
+
+	header(&b, "namebase_requests_total", "counter", "REST requests by endpoint.")
+	endpoints := make([]string, 0, len(m.requests))
+	for e := range m.requests {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		fmt.Fprintf(&b, "namebase_requests_total{endpoint=%s} %d\n", label(e), m.requests[e].count)
+	}
+
+	header(&b, "namebase_request_errors_total", "counter", "Failed REST requests by endpoint and error type.")
+	errKeys := make([][2]string, 0, len(m.errors))
+	for k := range m.errors {
+		errKeys = append(errKeys, k)
+	}
+	sort.Slice(errKeys, func(i, j int) bool {
+		if errKeys[i][0] != errKeys[j][0] {
+			return errKeys[i][0] < errKeys[j][0]
+		}
+		return errKeys[i][1] < errKeys[j][1]
+	})
+	for _, k := range errKeys {
+		fmt.Fprintf(&b, "namebase_request_errors_total{endpoint=%s,type=%s} %d\n",
+			label(k[0]), label(k[1]), m.errors[k])
+	}
+
+	header(&b, "namebase_request_duration_seconds", "histogram", "Latency of REST requests by endpoint.")
+	for _, e := range endpoints {
+		h := m.requests[e]
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "namebase_request_duration_seconds_bucket{endpoint=%s,le=%s} %d\n",
+				label(e), label(formatFloat(le)), h.counts[i])
+		}
+		fmt.Fprintf(&b, "namebase_request_duration_seconds_bucket{endpoint=%s,le=\"+Inf\"} %d\n", label(e), h.count)
+		fmt.Fprintf(&b, "namebase_request_duration_seconds_sum{endpoint=%s} %s\n", label(e), formatFloat(h.sum))
+		fmt.Fprintf(&b, "namebase_request_duration_seconds_count{endpoint=%s} %d\n", label(e), h.count)
+	}
+
+	header(&b, "namebase_rate_limiter_waits_total", "counter", "REST requests delayed by the rate limiter.")
+	fmt.Fprintf(&b, "namebase_rate_limiter_waits_total %d\n", m.waits)
+	header(&b, "namebase_rate_limiter_wait_seconds_total", "counter", "Time spent waiting for the rate limiter.")
+	fmt.Fprintf(&b, "namebase_rate_limiter_wait_seconds_total %s\n", formatFloat(m.waitSeconds))
+
+	header(&b, "namebase_ws_reconnects_total", "counter", "Websocket reconnections by endpoint.")
+	writeCounters(&b, "namebase_ws_reconnects_total", "endpoint", m.reconnects)
+	header(&b, "namebase_ws_messages_total", "counter", "Websocket messages received by endpoint.")
+	writeCounters(&b, "namebase_ws_messages_total", "endpoint", m.messages)
+
+	header(&b, "namebase_depth_resyncs_total", "counter", "Order book snapshots loaded by pair.")
+	pairs := make([]string, 0, len(m.resyncs))
+	resyncs := make(map[string]uint64, len(m.resyncs))
+	for p, n := range m.resyncs {
+		pairs = append(pairs, p.String())
+		resyncs[p.String()] = n
+	}
+	sort.Strings(pairs)
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "namebase_depth_resyncs_total{pair=%s} %d\n", label(p), resyncs[p])
+	}
+
+	header(&b, "namebase_depth_staleness_seconds", "gauge", "Time since the last order book update by pair.")
+	pairs = pairs[:0]
+	updated := make(map[string]time.Time, len(m.updated))
+	for p, at := range m.updated {
+		pairs = append(pairs, p.String())
+		updated[p.String()] = at
+	}
+	sort.Strings(pairs)
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "namebase_depth_staleness_seconds{pair=%s} %s\n",
+			label(p), formatFloat(now.Sub(updated[p]).Seconds()))
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func header(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeCounters(b *strings.Builder, name, key string, counters map[string]uint64) {
+	keys := make([]string, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%s} %d\n", name, key, label(k), counters[k])
+	}
+}
+
+// label quotes a label value, escaping backslashes, quotes and new lines
+func label(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	v = strings.Replace(v, "\n", `\n`, -1)
+	return `"` + v + `"`
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(f)
+}
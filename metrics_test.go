@@ -0,0 +1,121 @@
+package namebase
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	pair := NewCurrencyPair("hns", "btc")
+
+	m := NewPrometheusMetrics(0.1, 1)
+	m.now = func() time.Time { return now }
+
+	m.ObserveRequest("/api/v0/account", 50*time.Millisecond, "")
+	m.ObserveRequest("/api/v0/account", 2*time.Second, ErrTypeTimeout)
+	m.ObserveRequest(`/api/v0/"odd"`, 500*time.Millisecond, ErrTypeAPI)
+	m.ObserveLimiterWait(0)
+	m.ObserveLimiterWait(250 * time.Millisecond)
+	m.StreamReconnected(depthStreamPath)
+	m.StreamMessage(depthStreamPath)
+	m.StreamMessage(depthStreamPath)
+	m.DepthResync(pair)
+	m.DepthUpdated(pair, now.Add(-1500*time.Millisecond))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := `# HELP namebase_requests_total REST requests by endpoint.
+# TYPE namebase_requests_total counter
+namebase_requests_total{endpoint="/api/v0/\"odd\""} 1
+namebase_requests_total{endpoint="/api/v0/account"} 2
+# HELP namebase_request_errors_total Failed REST requests by endpoint and error type.
+# TYPE namebase_request_errors_total counter
+namebase_request_errors_total{endpoint="/api/v0/\"odd\"",type="api"} 1
+namebase_request_errors_total{endpoint="/api/v0/account",type="timeout"} 1
+# HELP namebase_request_duration_seconds Latency of REST requests by endpoint.
+# TYPE namebase_request_duration_seconds histogram
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/\"odd\"",le="0.1"} 0
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/\"odd\"",le="1"} 1
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/\"odd\"",le="+Inf"} 1
+namebase_request_duration_seconds_sum{endpoint="/api/v0/\"odd\""} 0.5
+namebase_request_duration_seconds_count{endpoint="/api/v0/\"odd\""} 1
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/account",le="0.1"} 1
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/account",le="1"} 1
+namebase_request_duration_seconds_bucket{endpoint="/api/v0/account",le="+Inf"} 2
+namebase_request_duration_seconds_sum{endpoint="/api/v0/account"} 2.05
+namebase_request_duration_seconds_count{endpoint="/api/v0/account"} 2
+# HELP namebase_rate_limiter_waits_total REST requests delayed by the rate limiter.
+# TYPE namebase_rate_limiter_waits_total counter
+namebase_rate_limiter_waits_total 1
+# HELP namebase_rate_limiter_wait_seconds_total Time spent waiting for the rate limiter.
+# TYPE namebase_rate_limiter_wait_seconds_total counter
+namebase_rate_limiter_wait_seconds_total 0.25
+# HELP namebase_ws_reconnects_total Websocket reconnections by endpoint.
+# TYPE namebase_ws_reconnects_total counter
+namebase_ws_reconnects_total{endpoint="/ws/v0/ticker/depth"} 1
+# HELP namebase_ws_messages_total Websocket messages received by endpoint.
+# TYPE namebase_ws_messages_total counter
+namebase_ws_messages_total{endpoint="/ws/v0/ticker/depth"} 2
+# HELP namebase_depth_resyncs_total Order book snapshots loaded by pair.
+# TYPE namebase_depth_resyncs_total counter
+namebase_depth_resyncs_total{pair="HNSBTC"} 1
+# HELP namebase_depth_staleness_seconds Time since the last order book update by pair.
+# TYPE namebase_depth_staleness_seconds gauge
+namebase_depth_staleness_seconds{pair="HNSBTC"} 1.5
+`
+	if got := rec.Body.String(); got != expected {
+		t.Errorf("unexpected metrics:\n%s", got)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+	s, client := newTestServer(t, WithMetrics(m))
+	defer s.Close()
+
+	client.GetAccount()
+	s.InjectAPIError("GET", "/api/v0/account", "UNAUTHORIZED", "invalid api key")
+	client.GetAccount()
+	s.InjectError("GET", "/api/v0/account", 502, "bad gateway")
+	client.GetAccount()
+
+	pair := NewCurrencyPair("hns", "btc")
+	if _, err := client.SubDepth(pair); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, depthStreamPath)
+	s.DropConnections()
+
+	var out string
+	for i := 0; i < 100; i++ {
+		var b strings.Builder
+		m.WriteTo(&b)
+		out = b.String()
+		if strings.Contains(out, `namebase_depth_resyncs_total{pair="HNSBTC"} 2`) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, line := range []string{
+		`namebase_requests_total{endpoint="/api/v0/account"} 3`,
+		`namebase_request_errors_total{endpoint="/api/v0/account",type="api"} 1`,
+		`namebase_request_errors_total{endpoint="/api/v0/account",type="http"} 1`,
+		`namebase_request_duration_seconds_count{endpoint="/api/v0/depth"} 2`,
+		`namebase_ws_reconnects_total{endpoint="/ws/v0/ticker/depth"} 1`,
+		`namebase_depth_resyncs_total{pair="HNSBTC"} 2`,
+		`namebase_depth_staleness_seconds{pair="HNSBTC"}`,
+	} {
+		if !strings.Contains(out, line+"\n") && !strings.Contains(out, line+" ") {
+			t.Errorf("missing %s in:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package namebase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPermission is returned, wrapped with the reason, when the client mode
+// or the account does not permit a request
+var ErrPermission = errors.New("permission denied")
+
+// Mode restricts what a client may do, whatever its API key permits
+type Mode int
+
+// Client modes, the zero value is ModeFull
+const (
+	// ModeFull permits everything
+	ModeFull Mode = iota
+	// ModeTrade permits trading but not withdrawals
+	ModeTrade
+	// ModeReadOnly only permits queries
+	ModeReadOnly
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeFull:
+		return "full"
+	case ModeTrade:
+		return "trade"
+	case ModeReadOnly:
+		return "read-only"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// WithMode restricts the client to m, e.g. ModeReadOnly for services
+// which only need market data and balances
+func WithMode(m Mode) ClientOption {
+	return func(nb *Namebase) {
+		nb.mode = m
+	}
+}
+
+// WithTradingCheck makes the client query Account.CanTrade before placing
+// orders, and refuse them when trading is disabled. The answer is reused
+// for ttl, so that orders placed in a burst do not each query the account
+func WithTradingCheck(ttl time.Duration) ClientOption {
+	return func(nb *Namebase) {
+		nb.tradeCheck = &tradeCheck{ttl: ttl}
+	}
+}
+
+// Mode returns the mode of the client
+func (nb *Namebase) Mode() Mode {
+	return nb.mode
+}
+
+// CanTrade reports whether orders may be placed, both by the mode
+// of the client and by Account.CanTrade, which is always queried
+func (nb *Namebase) CanTrade() (bool, error) {
+	if nb.mode == ModeReadOnly {
+		return false, nil
+	}
+
+	acct, err := nb.GetAccount()
+	if err != nil {
+		return false, err
+	}
+	nb.tradeCheck.store(acct.CanTrade)
+
+	return acct.CanTrade, nil
+}
+
+// permitTrading fails if orders may not be placed or canceled
+func (nb *Namebase) permitTrading() error {
+	if nb.mode == ModeReadOnly {
+		return fmt.Errorf("%w: %s client cannot trade", ErrPermission, nb.mode)
+	}
+
+	return nil
+}
+
+// permitOrder fails if a new order may not be placed,
+// checking the account when a trading check is enabled
+func (nb *Namebase) permitOrder() error {
+	if err := nb.permitTrading(); err != nil {
+		return err
+	}
+
+	if nb.tradeCheck == nil {
+		return nil
+	}
+
+	canTrade, ok := nb.tradeCheck.load()
+	if !ok {
+		var err error
+		if canTrade, err = nb.CanTrade(); err != nil {
+			return err
+		}
+	}
+
+	if !canTrade {
+		return fmt.Errorf("%w: trading is disabled for the account", ErrPermission)
+	}
+
+	return nil
+}
+
+// permitWithdrawal fails if funds may not be withdrawn
+func (nb *Namebase) permitWithdrawal() error {
+	if nb.mode != ModeFull {
+		return fmt.Errorf("%w: %s client cannot withdraw", ErrPermission, nb.mode)
+	}
+
+	return nil
+}
+
+// tradeCheck caches Account.CanTrade, a nil check caches nothing
+type tradeCheck struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	checked  time.Time
+	canTrade bool
+}
+
+func (c *tradeCheck) load() (canTrade, ok bool) {
+	if c == nil {
+		return false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checked.IsZero() || time.Since(c.checked) > c.ttl {
+		return false, false
+	}
+
+	return c.canTrade, true
+}
+
+func (c *tradeCheck) store(canTrade bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checked, c.canTrade = time.Now(), canTrade
+}
@@ -0,0 +1,82 @@
+package namebase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestModes(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	price := decimal.RequireFromString("0.00001")
+
+	readOnly, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithMode(ModeReadOnly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trade, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithMode(ModeTrade))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := len(srv.Requests())
+
+	if _, err := readOnly.LimitBuy(decimal.New(1, 0), price, pair); !errors.Is(err, ErrPermission) {
+		t.Errorf("read-only buy: %v", err)
+	}
+	if _, err := readOnly.CancelOrder(1, pair); !errors.Is(err, ErrPermission) {
+		t.Errorf("read-only cancel: %v", err)
+	}
+	if ok, err := readOnly.CanTrade(); ok || err != nil {
+		t.Errorf("read-only can trade: %v, %v", ok, err)
+	}
+	for _, client := range []*Namebase{readOnly, trade} {
+		if _, err := client.Withdraw("HNS", decimal.New(1, 0), testAddress, ""); !errors.Is(err, ErrPermission) {
+			t.Errorf("%s withdraw: %v", client.Mode(), err)
+		}
+	}
+
+	if len(srv.Requests()) != before {
+		t.Error("restricted requests have been sent")
+	}
+
+	if _, err := trade.LimitBuy(decimal.New(1, 0), price, pair); err != nil {
+		t.Errorf("trade buy: %v", err)
+	}
+
+	if _, err := readOnly.GetAccount(); err != nil {
+		t.Errorf("read-only query: %v", err)
+	}
+}
+
+func TestTradingCheck(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	price := decimal.RequireFromString("0.00001")
+
+	client, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithTradingCheck(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetCanTrade(false)
+	defer srv.SetCanTrade(true)
+
+	if _, err := client.LimitBuy(decimal.New(1, 0), price, pair); !errors.Is(err, ErrPermission) {
+		t.Errorf("buy with trading disabled: %v", err)
+	}
+
+	// the answer is cached until CanTrade queries the account again
+	srv.SetCanTrade(true)
+	if _, err := client.LimitBuy(decimal.New(1, 0), price, pair); !errors.Is(err, ErrPermission) {
+		t.Errorf("cached check: %v", err)
+	}
+
+	if ok, err := client.CanTrade(); !ok || err != nil {
+		t.Errorf("can trade: %v, %v", ok, err)
+	}
+	if _, err := client.LimitBuy(decimal.New(1, 0), price, pair); err != nil {
+		t.Error(err)
+	}
+}
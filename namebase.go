@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -15,7 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/shopspring/decimal"
 )
 
@@ -26,19 +24,39 @@ const (
 
 // Namebase is an API client of namebase exchange
 type Namebase struct {
-	apiKey     string
-	secretKey  string
+	creds      *credentialHolder
+	baseURL    string
+	wsURL      string
 	httpClient *http.Client
-	symbolInfo map[CurrencyPair]symbolInfo
+	limiter    *rateLimiter
+	guard      *withdrawalGuard
+	deposits   *depositAddrs
+	mode       Mode
+	tradeCheck *tradeCheck
+	logger     Logger
+	tracer     *tracer
+	metrics    Metrics
+	// strictDecoding rejects orders with unknown enum values
+	strictDecoding bool
+	symbolInfo     map[CurrencyPair]symbolInfo
 }
 
-// NewClient creates a API client
-func NewClient(key, secret string) (*Namebase, error) {
+// NewClient creates a API client, signing requests with key and secret
+// unless WithCredentials provides them
+func NewClient(key, secret string, opts ...ClientOption) (*Namebase, error) {
 	client := &Namebase{
-		apiKey:    key,
-		secretKey: secret,
+		creds:   &credentialHolder{p: NewStaticCredentials(key, secret)},
+		baseURL: baseURL,
+		wsURL:   baseWsURL,
 
 		httpClient: &http.Client{Timeout: time.Second * 10},
+		limiter:    newRateLimiter(defaultRateLimit, defaultRateInterval),
+		deposits:   &depositAddrs{addrs: make(map[Currency]DepositAddress)},
+		logger:     &StdLogger{},
+	}
+
+	for _, opt := range opts {
+		opt(client)
 	}
 
 	symbolInfo, err := client.exchInfo()
@@ -72,17 +90,26 @@ func (nb *Namebase) exchInfo() (map[CurrencyPair]symbolInfo, error) {
 
 // GetDepth queries the order book of pair
 func (nb *Namebase) GetDepth(pair CurrencyPair, size int) (*Depth, error) {
+	data, err := nb.rawDepth(pair, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDepth(data)
+}
+
+func (nb *Namebase) rawDepth(pair CurrencyPair, size int) ([]byte, error) {
 	params := make(map[string]interface{})
 	params["symbol"] = pair.String()
 	if size != 0 {
 		params["limit"] = size
 	}
 
-	data, err := nb.do(http.MethodGet, "/api/v0/depth", params, false)
-	if err != nil {
-		return nil, err
-	}
+	return nb.do(http.MethodGet, "/api/v0/depth", params, false)
+}
 
+// parseDepth decodes the order book returned by the depth endpoint
+func parseDepth(data []byte) (*Depth, error) {
 	d := &Depth{}
 
 	if err := json.Unmarshal(data, &d); err != nil {
@@ -99,28 +126,57 @@ func (nb *Namebase) GetDepth(pair CurrencyPair, size int) (*Depth, error) {
 	return d, nil
 }
 
-func (nb *Namebase) placeOrder(qty, price decimal.Decimal, pair CurrencyPair,
-	orderType string, side OrderSide) (*Order, error) {
-	info, ok := nb.symbolInfo[pair]
+// orderParams validates req against the symbol precision
+// and builds the request parameters of the order
+func (nb *Namebase) orderParams(req OrderRequest) (map[string]interface{}, error) {
+	info, ok := nb.symbolInfo[req.Pair]
 	if !ok {
 		return nil, errors.New("unsupported symbol")
 	}
 
-	qty = qty.Truncate(info.BasePrecision)
+	if req.Side != BuyOrder && req.Side != SellOrder {
+		return nil, fmt.Errorf("invalid order side: %s", req.Side)
+	}
+
+	qty := req.Quantity.Truncate(info.BasePrecision)
 
 	if qty.IsZero() {
 		return nil, errors.New("qty is zero")
 	}
 
-	price = price.Truncate(info.QuotePrecision)
+	if qty.IsNegative() {
+		return nil, errors.New("qty is negative")
+	}
 
 	params := make(map[string]interface{})
-	params["symbol"] = pair.String()
-	params["side"] = strings.ToUpper(string(side))
-	params["type"] = orderType
+	params["symbol"] = req.Pair.String()
+	params["side"] = strings.ToUpper(string(req.Side))
+	params["type"] = req.Type
 	params["quantity"] = qty.String()
-	if orderType == "LMT" {
+
+	switch req.Type {
+	case OrderTypeLimit:
+		price := req.Price.Truncate(info.QuotePrecision)
+		if !price.IsPositive() {
+			return nil, errors.New("price must be positive")
+		}
 		params["price"] = price.String()
+	case OrderTypeMarket:
+	default:
+		return nil, fmt.Errorf("unsupported order type: %s", req.Type)
+	}
+
+	return params, nil
+}
+
+func (nb *Namebase) submitOrder(req OrderRequest) (*Order, error) {
+	if err := nb.permitOrder(); err != nil {
+		return nil, err
+	}
+
+	params, err := nb.orderParams(req)
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := nb.do(http.MethodPost, "/api/v0/order", params, true)
@@ -134,9 +190,40 @@ func (nb *Namebase) placeOrder(qty, price decimal.Decimal, pair CurrencyPair,
 		return nil, err
 	}
 
+	if err := nb.checkOrders(o); err != nil {
+		return nil, err
+	}
+
 	return &o, nil
 }
 
+// checkOrders returns an error if strict decoding is on
+// and any of orders has an unknown status, type or side
+func (nb *Namebase) checkOrders(orders ...Order) error {
+	if !nb.strictDecoding {
+		return nil
+	}
+
+	for i := range orders {
+		if err := orders[i].checkEnums(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (nb *Namebase) placeOrder(qty, price decimal.Decimal, pair CurrencyPair,
+	orderType OrderType, side OrderSide) (*Order, error) {
+	return nb.submitOrder(OrderRequest{
+		Pair:     pair,
+		Side:     side,
+		Type:     orderType,
+		Quantity: qty,
+		Price:    price,
+	})
+}
+
 // GetAccount query account info
 func (nb *Namebase) GetAccount() (*Account, error) {
 	params := make(map[string]interface{})
@@ -155,28 +242,83 @@ func (nb *Namebase) GetAccount() (*Account, error) {
 	return &acct, nil
 }
 
+// GetPrice queries the latest price of pair
+func (nb *Namebase) GetPrice(pair CurrencyPair) (decimal.Decimal, error) {
+	params := make(map[string]interface{})
+	params["symbol"] = pair.String()
+
+	data, err := nb.do(http.MethodGet, "/api/v0/ticker/price", params, false)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	result := struct {
+		Price decimal.Decimal `json:"price"`
+	}{}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return decimal.Zero, err
+	}
+
+	return result.Price, nil
+}
+
+// AccountValue values all balances of acct in quote currency at the latest prices
+func (nb *Namebase) AccountValue(acct *Account, quote Currency) (decimal.Decimal, error) {
+	quote = NewCurrency(string(quote))
+	prices := make(map[CurrencyPair]decimal.Decimal)
+
+	for _, b := range acct.Balances {
+		asset := NewCurrency(string(b.Asset))
+		if asset == quote || b.Total().IsZero() {
+			continue
+		}
+
+		pair := CurrencyPair{asset, quote}
+		if _, ok := nb.symbolInfo[pair]; !ok {
+			pair = CurrencyPair{quote, asset}
+		}
+
+		if _, ok := nb.symbolInfo[pair]; !ok {
+			return decimal.Zero, fmt.Errorf("no market to value %s in %s", asset, quote)
+		}
+
+		price, err := nb.GetPrice(pair)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		prices[pair] = price
+	}
+
+	return acct.ValueIn(quote, prices)
+}
+
 // LimitBuy buy token at limited price
 func (nb *Namebase) LimitBuy(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
-	return nb.placeOrder(amount, price, pair, "LMT", BuyOrder)
+	return nb.placeOrder(amount, price, pair, OrderTypeLimit, BuyOrder)
 }
 
 // LimitSell sell token at limited price
 func (nb *Namebase) LimitSell(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
-	return nb.placeOrder(amount, price, pair, "LMT", SellOrder)
+	return nb.placeOrder(amount, price, pair, OrderTypeLimit, SellOrder)
 }
 
 // MarketBuy buy token at market price
 func (nb *Namebase) MarketBuy(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
-	return nb.placeOrder(amount, decimal.Zero, pair, "MKT", BuyOrder)
+	return nb.placeOrder(amount, decimal.Zero, pair, OrderTypeMarket, BuyOrder)
 }
 
 // MarketSell sells token at market price
 func (nb *Namebase) MarketSell(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
-	return nb.placeOrder(amount, decimal.Zero, pair, "MKT", SellOrder)
+	return nb.placeOrder(amount, decimal.Zero, pair, OrderTypeMarket, SellOrder)
 }
 
-// CancelOrder implements the API interface
+// CancelOrder cancels an open order, it implements the Trading interface
 func (nb *Namebase) CancelOrder(orderID int, pair CurrencyPair) (bool, error) {
+	if err := nb.permitTrading(); err != nil {
+		return false, err
+	}
+
 	params := make(map[string]interface{})
 	params["symbol"] = pair.String()
 	params["orderId"] = orderID
@@ -206,6 +348,10 @@ func (nb *Namebase) GetOrder(orderID int, pair CurrencyPair) (*Order, error) {
 		return nil, err
 	}
 
+	if err := nb.checkOrders(*o); err != nil {
+		return nil, err
+	}
+
 	return o, nil
 }
 
@@ -225,6 +371,10 @@ func (nb *Namebase) OpenOrders(pair CurrencyPair) ([]Order, error) {
 		return nil, err
 	}
 
+	if err := nb.checkOrders(orders...); err != nil {
+		return nil, err
+	}
+
 	return orders, nil
 }
 
@@ -238,6 +388,10 @@ func (nb *Namebase) GetKlines(pair CurrencyPair, interval KlineInterval, limit i
 		params["limit"] = limit
 	}
 
+	return nb.getKlines(params)
+}
+
+func (nb *Namebase) getKlines(params map[string]interface{}) ([]Kline, error) {
 	data, err := nb.do(http.MethodGet, "/api/v0/ticker/klines", params, false)
 	if err != nil {
 		return nil, err
@@ -252,64 +406,151 @@ func (nb *Namebase) GetKlines(pair CurrencyPair, interval KlineInterval, limit i
 	return klines, nil
 }
 
-// GetTrades implements the API interface
-// func (nb *Namebase) GetTrades(pair CurrencyPair, size int) ([]*Trade, error) {
-// 	panic("")
-// }
+// DepositAddr returns the current deposit address of symbol,
+// it is generated by the first call and reused by the next ones
+func (nb *Namebase) DepositAddr(symbol Currency) (*DepositAddress, error) {
+	if addr, ok := nb.deposits.get(symbol); ok {
+		return addr, nil
+	}
+
+	unlock := nb.deposits.lock(symbol)
+	defer unlock()
 
-// DepositAddr generates a deposit address
-// for now, no memo is needed
-func (nb *Namebase) DepositAddr(symbol Currency) (string, error) {
+	// the address may have been generated while waiting for the lock
+	if addr, ok := nb.deposits.get(symbol); ok {
+		return addr, nil
+	}
+
+	return nb.NewDepositAddr(symbol)
+}
+
+// NewDepositAddr generates a new deposit address of symbol, e.g. one per
+// customer, which becomes the current address returned by DepositAddr
+func (nb *Namebase) NewDepositAddr(symbol Currency) (*DepositAddress, error) {
 	params := make(map[string]interface{})
 
 	params["asset"] = string(symbol)
 
 	data, err := nb.do(http.MethodPost, "/api/v0/deposit/address", params, true)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	result := struct {
-		Address string `json:"address"`
-		Success bool   `json:"success"`
-		Asset   string `json:"asset"`
-	}{}
+	var addr DepositAddress
+	if err := json.Unmarshal(data, &addr); err != nil {
+		return nil, err
+	}
 
-	if err := json.Unmarshal(data, &result); err != nil {
-		return "", err
+	if !addr.Success || addr.Address == "" {
+		return nil, fmt.Errorf("failed to generate %s deposit address", symbol)
 	}
 
-	return result.Address, nil
+	nb.deposits.set(symbol, &addr)
+
+	return &addr, nil
 }
 
 // Withdraw withdraw currencies from exchange,
-func (nb *Namebase) Withdraw(symbol Currency, amount decimal.Decimal, address, memo string) error {
+// the memo is only sent if it is not empty. The address and the precision
+// of the amount are checked first, invalid addresses return an error wrapping
+// ErrInvalidAddress. Clients not in ModeFull return an error wrapping
+// ErrPermission. With a WithdrawalPolicy, withdrawals it denies return
+// an error wrapping ErrWithdrawalDenied, and dry runs return a withdrawal
+// without ID
+func (nb *Namebase) Withdraw(symbol Currency, amount decimal.Decimal, address, memo string) (*Withdrawal, error) {
+	if err := nb.permitWithdrawal(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateAddress(symbol, address); err != nil {
+		return nil, err
+	}
+
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("withdrawal amount %s is not positive", amount)
+	}
+
+	if prec, ok := nb.assetPrecision(symbol); ok && !amount.Equal(amount.Truncate(prec)) {
+		return nil, fmt.Errorf("withdrawal amount %s has more than %d decimals", amount, prec)
+	}
+
+	w, err := nb.guard.reserve(WithdrawalRequest{
+		Asset: symbol, Amount: amount, Address: address, Memo: memo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if nb.guard.dryRun() {
+		nb.log().Info("withdrawal dry run", "endpoint", "/api/v0/withdraw", "asset", symbol,
+			"amount", amount, "address", address, "memo", memo)
+		return &Withdrawal{
+			Asset: symbol, Amount: amount, Address: address, Memo: memo,
+		}, nil
+	}
+
 	params := make(map[string]interface{})
 
 	params["asset"] = string(symbol)
 
 	params["address"] = address
 	params["amount"] = amount.String()
+	if memo != "" {
+		params["memo"] = memo
+	}
 
 	data, err := nb.do(http.MethodPost, "/api/v0/withdraw", params, true)
 	if err != nil {
-		return err
+		nb.guard.release(w)
+		return nil, err
 	}
 
-	log.Print("withdraw raw: ", string(data))
+	result := struct {
+		Withdrawal
+		Success bool `json:"success"`
+	}{}
 
-	return nil
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	if !result.Success {
+		nb.guard.release(w)
+		return nil, fmt.Errorf("withdrawal of %s %s failed", amount, symbol)
+	}
+
+	return &result.Withdrawal, nil
 }
 
-// OrderHistory implements the API interface
-func (nb *Namebase) OrderHistory(pair CurrencyPair, size int) ([]Order, error) {
-	panic("")
+// assetPrecision returns the decimals of asset in the symbols it is traded in.
+// An asset traded with different precisions gets the smallest of them,
+// so that an amount accepted for it is valid in every symbol
+func (nb *Namebase) assetPrecision(asset Currency) (int32, bool) {
+	asset = NewCurrency(string(asset))
+
+	var prec int32
+	found := false
+	for pair, info := range nb.symbolInfo {
+		p := info.QuotePrecision
+		if pair.Base == asset {
+			p = info.BasePrecision
+		} else if pair.Quote != asset {
+			continue
+		}
+
+		if !found || p < prec {
+			prec, found = p, true
+		}
+	}
+
+	return prec, found
 }
 
-// SubKlines implements the API interface
-// func (nb *Namebase) SubKlines(pair CurrencyPair, interval KlineInterval) (chan Kline, error) {
-// 	panic("not implemented")
-// }
+// OrderHistory is not implemented yet and always returns an error,
+// so it is not part of the Trading interface
+func (nb *Namebase) OrderHistory(pair CurrencyPair, size int) ([]Order, error) {
+	return nil, errors.New("not implemented")
+}
 
 func updateDepth(data DepthRecords, el DepthRecord, ask bool) DepthRecords {
 	index := 0
@@ -345,93 +586,47 @@ func updateDepth(data DepthRecords, el DepthRecord, ask bool) DepthRecords {
 
 // SubDepth subscribes order book updates of a trading pair
 func (nb *Namebase) SubDepth(pair CurrencyPair) (chan Depth, error) {
-	path := baseWsURL + "/ws/v0/ticker/depth"
-	wsConn, _, err := websocket.DefaultDialer.Dial(path, nil)
-	if err != nil {
-		log.Print("[namebase] failed to establish a websocket connection", err)
-		return nil, err
-	}
-
 	chDepth := make(chan Depth, 1)
+	book := &depthBook{}
 
-	snapshot, err := nb.GetDepth(pair, 50)
-	if err != nil {
-		return nil, err
-	}
-
-	//log.Print("last event id: ", snapshot.Seq)
-	go func() {
-		d := struct {
-			Depth
-			EventType    string
-			EventTime    int64
-			Symbol       string
-			FirstEventID int64
-		}{}
-
-		for {
-			_, data, err := wsConn.ReadMessage()
-			if err != nil {
-				log.Printf("[namebase] ERROR\tfailed to read from websocket: %v, local addr: %s",
-					err, wsConn.LocalAddr())
-				wsConn.Close()
-				wsConn, _, err = websocket.DefaultDialer.Dial(path, nil)
-				if err != nil {
-					log.Print("[namebase] failed to reconnect to websocket", err)
-					// TODO notify subscriber about this error
-					return
-				}
-
-				snapshot, err = nb.GetDepth(pair, 50)
-				if err != nil {
-					return
-				}
-
-				continue
-			}
-
-			// reset
-			d.Asks = d.Asks[:0]
-			d.Bids = d.Bids[:0]
-			if err := json.Unmarshal(data, &d); err != nil {
-				log.Printf("failed to unmarshal: %s, raw data: %s", err, string(data))
-				continue
-			}
-
-			if len(d.Asks) == 0 && len(d.Bids) == 0 {
-				// FirstEventID = -1
-				continue
-			}
-			//log.Printf("first: %d, last: %d", d.FirstEventID, d.LastEventID)
-
-			// TODO check continuity
-			if d.FirstEventID > snapshot.LastEventID {
-				for _, ask := range d.Asks {
-					snapshot.Asks = updateDepth(snapshot.Asks, ask, true)
-				}
-
-				for _, bid := range d.Bids {
-					snapshot.Bids = updateDepth(snapshot.Bids, bid, false)
-				}
-			}
+	resync := func() error {
+		snapshot, err := nb.GetDepth(pair, 50)
+		if err != nil {
+			return err
+		}
 
-			snapshot.LastEventID = d.LastEventID
+		book.reset(snapshot)
+		nb.observe().DepthResync(pair)
+		nb.observe().DepthUpdated(pair, time.Now())
+		return nil
+	}
 
-			// deep copy
-			depth := Depth{
-				//Pair:    pair,
-				Ts:          d.EventTime,
-				LastEventID: snapshot.LastEventID,
-				Asks:        make([]DepthRecord, len(snapshot.Asks)),
-				Bids:        make([]DepthRecord, len(snapshot.Bids)),
+	err := nb.stream(depthStreamPath, pair, nil, nil, resync, func(data []byte) {
+		depth, ok, err := book.apply(data)
+		if errors.Is(err, ErrDepthGap) {
+			nb.log().Warn("depth stream out of sync, resyncing", "endpoint", depthStreamPath,
+				"pair", pair, "error", err)
+			if err := resync(); err != nil {
+				nb.log().Error("failed to resync depth", "endpoint", depthStreamPath,
+					"pair", pair, "error", err)
 			}
+			return
+		}
 
-			copy(depth.Asks, snapshot.Asks)
-			copy(depth.Bids, snapshot.Bids)
+		if err != nil {
+			nb.log().Error("failed to unmarshal depth", "endpoint", depthStreamPath,
+				"pair", pair, "error", err, "data", string(data))
+			return
+		}
 
+		if ok {
+			nb.observe().DepthUpdated(pair, time.Now())
 			chDepth <- depth
 		}
-	}()
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return chDepth, nil
 }
@@ -443,47 +638,27 @@ func (nb *Namebase) SubDepth(pair CurrencyPair) (chan Depth, error) {
 // SubTrades subscribes trade info of a trading pair
 // this interface seems down for now
 func (nb *Namebase) SubTrades(pair CurrencyPair) (chan Trade, error) {
-	path := baseWsURL + "/ws/v0/stream/trades'"
-	wsConn, _, err := websocket.DefaultDialer.Dial(path, nil)
-	if err != nil {
-		log.Print("[namebase] failed to establish a websocket connection", err)
-		return nil, err
-	}
-
 	chTrade := make(chan Trade)
 
-	go func() {
-		t := struct {
-			Trade
-			EventType string `json:"eventType"`
-			EventTime int64  `json:"eventTime"`
-			Symbol    string `json:"symbol"`
-		}{}
-
-		for {
-			_, data, err := wsConn.ReadMessage()
-			if err != nil {
-				log.Printf("[namebase] ERROR\tfailed to read from websocket: %v, local addr: %s",
-					err, wsConn.LocalAddr())
-				wsConn.Close()
-				wsConn, _, err = websocket.DefaultDialer.Dial(path, nil)
-				if err != nil {
-					log.Print("[namebase] failed to reconnect to websocket", err)
-					// TODO notify subscriber about this error
-					return
-				}
-
-				continue
-			}
-
-			if err := json.Unmarshal(data, &t); err != nil {
-				log.Printf("failed to unmarshal: %s, raw data: %s", err, string(data))
-				continue
-			}
+	t := struct {
+		Trade
+		EventType string `json:"eventType"`
+		EventTime int64  `json:"eventTime"`
+		Symbol    string `json:"symbol"`
+	}{}
 
-			chTrade <- t.Trade
+	err := nb.stream(tradesStreamPath, pair, nil, nil, nil, func(data []byte) {
+		if err := json.Unmarshal(data, &t); err != nil {
+			nb.log().Error("failed to unmarshal trade", "endpoint", tradesStreamPath,
+				"pair", pair, "error", err, "data", string(data))
+			return
 		}
-	}()
+
+		chTrade <- t.Trade
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return chTrade, nil
 }
@@ -492,6 +667,7 @@ func (nb *Namebase) SubTrades(pair CurrencyPair) (chan Trade, error) {
 // sign indicates whether the api call should be done with signed payload
 func (nb *Namebase) do(method, endpoint string, params map[string]interface{}, sign bool) ([]byte, error) {
 	var req *http.Request
+	var payload []byte
 	var err error
 	if sign {
 		params["timestamp"] = time.Now().UnixNano() / int64(time.Millisecond) / int64(time.Nanosecond)
@@ -504,50 +680,62 @@ func (nb *Namebase) do(method, endpoint string, params map[string]interface{}, s
 				urlParams.Set(k, fmt.Sprint(v))
 			}
 
-			path = fmt.Sprintf("%s%s?%s", baseURL, endpoint, urlParams.Encode())
+			path = fmt.Sprintf("%s%s?%s", nb.baseURL, endpoint, urlParams.Encode())
 		} else {
-			path = baseURL + endpoint
+			path = nb.baseURL + endpoint
 		}
 		req, err = http.NewRequest(method, path, nil)
 	} else {
-		payload, _ := json.Marshal(params)
-		req, err = http.NewRequest(method, fmt.Sprintf("%s%s", baseURL, endpoint), bytes.NewReader(payload))
+		payload, _ = json.Marshal(params)
+		req, err = http.NewRequest(method, fmt.Sprintf("%s%s", nb.baseURL, endpoint), bytes.NewReader(payload))
 		req.Header.Add("Content-Type", "application/json")
 	}
 
-	if sign {
-		req.SetBasicAuth(nb.apiKey, nb.secretKey)
-	}
-
 	if err != nil {
 		return nil, err
 	}
 
+	var creds Credentials
+	if sign {
+		if creds, err = nb.creds.get(); err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(creds.Key, creds.Secret)
+	}
+
 	req.Header.Add("Accept", "application/json")
-	// dump, _ := httputil.DumpRequest(req, true)
-	// log.Print("raw: ", string(dump))
+	nb.observe().ObserveLimiterWait(nb.limiter.Wait())
+
+	start := time.Now()
 	resp, err := nb.httpClient.Do(req)
 	if err != nil {
+		latency := time.Since(start)
+		nb.tracer.request(req, payload, nil, nil, latency, err, creds)
 		if err, ok := err.(net.Error); ok && err.Timeout() {
+			nb.observe().ObserveRequest(endpoint, latency, ErrTypeTimeout)
 			return nil, errors.New("timeout")
 		}
 
+		nb.observe().ObserveRequest(endpoint, latency, ErrTypeNetwork)
 		return nil, err
 	}
 
 	defer resp.Body.Close()
 
-	// dump, _ = httputil.DumpResponse(resp, true)
-	// log.Print("raw resp: ", string(dump))
+	body, err := ioutil.ReadAll(resp.Body)
+	latency := time.Since(start)
+	nb.tracer.request(req, payload, resp, body, latency, err, creds)
 
 	if resp.StatusCode != 200 {
+		nb.observe().ObserveRequest(endpoint, latency, ErrTypeHTTP)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
 		dump, _ := httputil.DumpResponse(resp, true)
 		return nil, fmt.Errorf("http code: %d, body: %s",
 			resp.StatusCode, string(dump))
 	}
-	
-	body, err := ioutil.ReadAll(resp.Body)
+
 	if err != nil {
+		nb.observe().ObserveRequest(endpoint, latency, ErrTypeRead)
 		return nil, err
 	}
 
@@ -557,8 +745,11 @@ func (nb *Namebase) do(method, endpoint string, params map[string]interface{}, s
 	}{}
 
 	if err := json.Unmarshal(body, &result); err == nil && result.Code != "" {
+		nb.observe().ObserveRequest(endpoint, latency, ErrTypeAPI)
 		return nil, errors.New(result.Message)
 	}
 
+	nb.observe().ObserveRequest(endpoint, latency, "")
+
 	return body, err
 }
@@ -2,60 +2,109 @@ package namebase
 
 import (
 	"log"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+var (
+	srv *namebasetest.Server
+	nb  *Namebase
 )
 
-var nb, _ = NewClient("", "")
+func TestMain(m *testing.M) {
+	srv = namebasetest.NewServer()
+	srv.SetDepth("HNSBTC", namebasetest.Depth{
+		LastEventID: 10,
+		Bids:        []namebasetest.Level{{"0.00000980", "500"}, {"0.00000970", "800"}},
+		Asks:        []namebasetest.Level{{"0.00001010", "300"}, {"0.00001020", "900"}},
+	})
+	srv.SetAccount(10, 20, namebasetest.Balance{
+		Asset: "HNS", Unlocked: "2500", LockedInOrders: "0", CanDeposit: true, CanWithdraw: true,
+	})
+
+	var err error
+	nb, err = NewClient("key", "secret",
+		WithBaseURL(srv.URL), WithWebsocketURL(srv.WsURL))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
 
 func TestExchInfo(t *testing.T) {
 	if symbols, err := nb.exchInfo(); err != nil {
 		t.Errorf("exch info error: %v", err)
-	} else {
-		log.Print(symbols)
+	} else if _, ok := symbols[NewCurrencyPair("hns", "btc")]; !ok {
+		t.Errorf("HNSBTC is missing: %v", symbols)
 	}
 }
 
 func TestGetAccount(t *testing.T) {
 	if acct, err := nb.GetAccount(); err != nil {
 		t.Error(err)
-	} else {
-		log.Printf("%+v", acct)
+	} else if b, _ := acct.Balance("HNS"); b.Unlocked.String() != "2500" {
+		t.Errorf("unexpected account: %+v", acct)
 	}
 }
 
+// testAddress is a valid address of nobody, the witness program is 0x01 to 0x14
+const testAddress = "hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw"
+
 func TestWithdraw(t *testing.T) {
 	tokenAmount := decimal.NewFromFloat(2000)
-	if err := nb.Withdraw("HNS", tokenAmount,
-		"hs1qc7kmegpjkn4qrhuactul9feu69nvsqnjpkk6sy", ""); err != nil {
+	if w, err := nb.Withdraw("HNS", tokenAmount, testAddress, "note"); err != nil {
 		t.Error(err)
+	} else if w.ID == "" || w.Status != "PENDING" || w.Amount.String() != "2000" {
+		t.Errorf("unexpected withdrawal: %+v", w)
+	}
+
+	requests := srv.Requests()
+	if r := requests[len(requests)-1]; r.Path != "/api/v0/withdraw" || r.Params["memo"] != "note" {
+		t.Errorf("unexpected request: %+v", r)
 	}
 }
 
 func TestCancelOrder(t *testing.T) {
-	if _, err := nb.CancelOrder(1733667,
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "NEW",
+		Type: "LMT", Side: "BUY", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "0"})
+
+	if _, err := nb.CancelOrder(id,
 		NewCurrencyPair("hns", "btc")); err != nil {
 		t.Errorf("error: %v", err)
 	}
+
+	if o, _ := srv.Order(id); o.Status != "CANCELED" {
+		t.Errorf("order is %s", o.Status)
+	}
 }
 
 func TestGetOrder(t *testing.T) {
-	if order, err := nb.GetOrder(1733236,
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "PARTIALLY_FILLED",
+		Type: "LMT", Side: "SELL", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "40"})
+
+	if order, err := nb.GetOrder(id,
 		NewCurrencyPair("hns", "btc")); err != nil {
 		t.Error(err)
-	} else {
-		log.Printf("%+v", order)
+	} else if order.RemainingQuantity().String() != "60" || !order.IsOpen() {
+		t.Errorf("unexpected order: %+v", order)
 	}
-
 }
 
 func TestMarketBuy(t *testing.T) {
 	if o, err := nb.MarketBuy(decimal.NewFromFloat(16.0408695),
 		NewCurrencyPair("HNS", "btc")); err != nil {
 		t.Error(err)
-	} else {
-		log.Print(o)
+	} else if o.Status != OrderStatusFilled || o.ExecutedQuantity.String() != "16.040869" {
+		t.Errorf("unexpected order: %+v", o)
 	}
 }
 
@@ -63,8 +112,8 @@ func TestLimitBuy(t *testing.T) {
 	if o, err := nb.LimitBuy(decimal.NewFromFloat(100), decimal.NewFromFloat(0.21),
 		NewCurrencyPair("hns", "btc")); err != nil {
 		t.Error(err)
-	} else {
-		log.Print(o)
+	} else if o.Side != BuyOrder || o.Status != OrderStatusNew {
+		t.Errorf("unexpected order: %+v", o)
 	}
 }
 
@@ -76,40 +125,205 @@ func TestLimitSell(t *testing.T) {
 		decimal.NewFromFloat(0.0000104),
 		NewCurrencyPair("hns", "btc")); err != nil {
 		t.Error(err)
-	} else {
-		log.Print(o)
+	} else if o.Side != SellOrder || o.Price.String() != "0.0000104" {
+		t.Errorf("unexpected order: %+v", o)
 	}
 }
 
 func TestGetDepth(t *testing.T) {
 	if d, err := nb.GetDepth(NewCurrencyPair("hns", "btc"), 0); err != nil {
 		t.Error(err)
-	} else {
-		log.Printf("ask 1: %+v, bid 1: %+v", d.Asks[0], d.Bids[0])
+	} else if d.Asks[0].Price.String() != "0.0000102" || d.Bids[0].Price.String() != "0.0000098" {
+		t.Errorf("ask 1: %+v, bid 1: %+v", d.Asks[0], d.Bids[0])
+	}
+}
+
+func TestInjectedError(t *testing.T) {
+	srv.InjectAPIError("GET", "/api/v0/account", "UNAUTHORIZED", "invalid api key")
+
+	if _, err := nb.GetAccount(); err == nil || err.Error() != "invalid api key" {
+		t.Errorf("expected injected error, got %v", err)
 	}
 }
 
 func TestSubDepth(t *testing.T) {
-	pair := NewCurrencyPair("hns", "btc")
+	s, client := newTestServer(t)
+	defer s.Close()
+	s.SetDepth("HNSBTC", namebasetest.Depth{
+		LastEventID: 10,
+		Bids:        []namebasetest.Level{{"0.00000980", "500"}},
+		Asks:        []namebasetest.Level{{"0.00001010", "300"}},
+	})
 
-	if ch, err := nb.SubDepth(pair); err != nil {
-		t.Error(err)
-	} else {
-		for d := range ch {
-			log.Printf("ask 1: %s", d.Asks[0].Price)
-			log.Printf("bid 1: %s\n", d.Bids[0].Price)
+	ch, err := client.SubDepth(NewCurrencyPair("hns", "btc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitSubscribers(t, s, depthStreamPath)
+	s.PushDepth(map[string]interface{}{
+		"eventType":    "depthUpdate",
+		"firstEventId": 11,
+		"lastEventId":  11,
+		"bids":         [][]string{{"0.00000990", "100"}},
+		"asks":         [][]string{},
+	})
+
+	select {
+	case d := <-ch:
+		if d.LastEventID != 11 || d.Bids[0].Price.String() != "0.0000099" {
+			t.Errorf("unexpected book: %+v", d)
 		}
+	case <-time.After(5 * time.Second):
+		t.Error("no depth update")
+	}
+}
+
+func TestSubDepthGap(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+	s.SetDepth("HNSBTC", namebasetest.Depth{
+		LastEventID: 10,
+		Bids:        []namebasetest.Level{{"0.00000980", "500"}},
+		Asks:        []namebasetest.Level{{"0.00001010", "300"}},
+	})
+
+	ch, err := client.SubDepth(NewCurrencyPair("hns", "btc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitSubscribers(t, s, depthStreamPath)
+
+	// events 11 and 12 are lost, the book is resynced from the new snapshot
+	s.SetDepth("HNSBTC", namebasetest.Depth{
+		LastEventID: 13,
+		Bids:        []namebasetest.Level{{"0.00000990", "100"}, {"0.00000980", "500"}},
+		Asks:        []namebasetest.Level{{"0.00001010", "300"}},
+	})
+	s.PushDepth(map[string]interface{}{
+		"firstEventId": 13,
+		"lastEventId":  13,
+		"bids":         [][]string{{"0.00000990", "100"}},
+	})
+	s.PushDepth(map[string]interface{}{
+		"firstEventId": 14,
+		"lastEventId":  14,
+		"bids":         [][]string{{"0.00000980", "0"}},
+	})
+
+	select {
+	case d := <-ch:
+		if d.LastEventID != 14 || len(d.Bids) != 1 || d.Bids[0].Price.String() != "0.0000099" {
+			t.Errorf("unexpected book: %+v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no depth update")
+	}
+
+	var snapshots int
+	for _, r := range s.Requests() {
+		if r.Path == "/api/v0/depth" {
+			snapshots++
+		}
+	}
+	if snapshots != 2 {
+		t.Errorf("expected 2 snapshots, got %d", snapshots)
+	}
+}
+
+func TestStreamWaitGroup(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	received, release := make(chan struct{}), make(chan struct{})
+	err := client.stream(tradesStreamPath, NewCurrencyPair("hns", "btc"), done, &wg, nil,
+		func([]byte) {
+			close(received)
+			<-release
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitSubscribers(t, s, tradesStreamPath)
+	s.PushTrade(map[string]interface{}{"tradeId": 1})
+	<-received
+
+	close(done)
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("stream is done while a message is being handled")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream is not stopped")
 	}
 }
 
 func TestSubTrade(t *testing.T) {
-	pair := NewCurrencyPair("hns", "btc")
+	s, client := newTestServer(t)
+	defer s.Close()
 
-	if ch, err := nb.SubTrades(pair); err != nil {
-		t.Error(err)
-	} else {
-		for t := range ch {
-			log.Printf("latest trade: %+v", t)
+	ch, err := client.SubTrades(NewCurrencyPair("hns", "btc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitSubscribers(t, s, tradesStreamPath)
+	s.PushTrade(map[string]interface{}{
+		"eventType": "trade",
+		"tradeId":   7,
+		"price":     "0.00001",
+		"quantity":  "10",
+	})
+
+	select {
+	case trade := <-ch:
+		if trade.TradeID != 7 || trade.Quantity.String() != "10" {
+			t.Errorf("unexpected trade: %+v", trade)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no trade")
+	}
+}
+
+// newTestServer starts a fake exchange of its own and a client of it,
+// with opts applied last. Tests subscribing to streams or adding records
+// use it, so that they do not leak into the tests sharing srv
+func newTestServer(t *testing.T, opts ...ClientOption) (*namebasetest.Server, *Namebase) {
+	s := namebasetest.NewServer()
+	opts = append([]ClientOption{WithBaseURL(s.URL), WithWebsocketURL(s.WsURL),
+		WithRateLimit(0, 0), WithLogger(NopLogger{})}, opts...)
+
+	client, err := NewClient("key", "secret", opts...)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+
+	return s, client
+}
+
+// waitSubscribers waits until a client has connected to the stream of s
+func waitSubscribers(t *testing.T, s *namebasetest.Server, path string) {
+	for i := 0; i < 100; i++ {
+		if s.Subscribers(path) > 0 {
+			return
 		}
+		time.Sleep(10 * time.Millisecond)
 	}
+	t.Fatalf("no subscriber of %s", path)
 }
@@ -0,0 +1,236 @@
+package namebasetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// redacted replaces the credentials of recorded requests
+const redacted = "REDACTED"
+
+// Interaction is a recorded REST request and its response.
+// URL is the path and query of the request and Body the request body, both
+// without the timestamp parameter of signed requests, so that replaying
+// does not depend on the time
+type Interaction struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body,omitempty"`
+	Status   int         `json:"status"`
+	Response string      `json:"response"`
+}
+
+// Recorder is an http.RoundTripper which records the requests it sends and
+// their responses, to be saved as a fixture file and served by a Replayer.
+// Basic auth headers are redacted, so fixtures can be recorded against the
+// exchange with real credentials.
+//
+//	rec := namebasetest.NewRecorder("testdata/fixtures.json", nil)
+//	nb, err := namebase.NewClient(key, secret,
+//		namebase.WithHTTPClient(&http.Client{Transport: rec}))
+//	...
+//	err = rec.Save()
+type Recorder struct {
+	path string
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder creates a Recorder saving to path, which sends requests
+// with next, or http.DefaultTransport when next is nil
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{path: path, next: next}
+}
+
+// RoundTrip sends the request and records it with its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	header := http.Header{}
+	for k, v := range req.Header {
+		header[k] = v
+	}
+	if _, _, ok := req.BasicAuth(); ok {
+		header.Del("Authorization")
+		header.Set("Authorization", "Basic "+redacted)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:   req.Method,
+		URL:      fixtureURL(req.URL),
+		Header:   header,
+		Body:     fixtureBody(body),
+		Status:   resp.StatusCode,
+		Response: string(data),
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Interactions returns what has been recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the fixture file
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// Replayer is an http.RoundTripper serving the responses of a fixture file
+// without any network access. A request is answered by the first interaction
+// not served yet with the same method, URL and body, so repeated requests get
+// the responses in the order they were recorded
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	served       []bool
+}
+
+// NewReplayer loads the fixture file at path
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("fixture %s: %v", path, err)
+	}
+
+	return &Replayer{
+		interactions: interactions,
+		served:       make([]bool, len(interactions)),
+	}, nil
+}
+
+// RoundTrip serves the recorded response of the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	u, b := fixtureURL(req.URL), fixtureBody(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.interactions {
+		if r.served[i] || in.Method != req.Method || in.URL != u || in.Body != b {
+			continue
+		}
+		r.served[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(in.Response))),
+			ContentLength: int64(len(in.Response)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no fixture for %s %s %s", req.Method, u, b)
+}
+
+// Unserved returns the interactions which have not been requested,
+// tests can check it is empty to catch requests the client no longer sends
+func (r *Replayer) Unserved() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []Interaction
+	for i, in := range r.interactions {
+		if !r.served[i] {
+			result = append(result, in)
+		}
+	}
+
+	return result
+}
+
+// fixtureURL returns the path and query of u without the timestamp
+func fixtureURL(u *url.URL) string {
+	q := u.Query()
+	q.Del("timestamp")
+	if len(q) == 0 {
+		return u.Path
+	}
+
+	return u.Path + "?" + q.Encode()
+}
+
+// fixtureBody returns a JSON object body without the timestamp,
+// with its keys sorted
+func fixtureBody(body []byte) string {
+	var params map[string]interface{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return string(body)
+	}
+
+	delete(params, "timestamp")
+	if len(params) == 0 {
+		return ""
+	}
+
+	data, _ := json.Marshal(params)
+	return string(data)
+}
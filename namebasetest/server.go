@@ -0,0 +1,829 @@
+// Package namebasetest provides an in-process fake of the Namebase exchange
+// for tests, serving the REST endpoints and websocket streams used by the client.
+//
+//	srv := namebasetest.NewServer()
+//	defer srv.Close()
+//
+//	nb, err := namebase.NewClient("key", "secret",
+//		namebase.WithBaseURL(srv.URL), namebase.WithWebsocketURL(srv.WsURL))
+package namebasetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Symbol is a trading pair listed by the info endpoint
+type Symbol struct {
+	Symbol         string   `json:"symbol"`
+	Status         string   `json:"status"`
+	BaseAsset      string   `json:"baseAsset"`
+	BasePrecision  int32    `json:"basePrecision"`
+	QuoteAsset     string   `json:"quoteAsset"`
+	QuotePrecision int32    `json:"quotePrecision"`
+	OrderTypes     []string `json:"orderTypes"`
+}
+
+// Level is a price level of the order book, [price, amount]
+type Level [2]string
+
+// Depth is the order book served by the depth endpoint, asks are in ascending order
+type Depth struct {
+	LastEventID int64   `json:"lastEventId"`
+	Bids        []Level `json:"bids"`
+	Asks        []Level `json:"asks"`
+}
+
+// Order is an order held by the server
+type Order struct {
+	OrderID          int    `json:"orderId"`
+	Symbol           string `json:"-"`
+	Price            string `json:"price"`
+	OriginalQuantity string `json:"originalQuantity"`
+	ExecutedQuantity string `json:"executedQuantity"`
+	Status           string `json:"status"`
+	Type             string `json:"type"`
+	Side             string `json:"side"`
+	CreatedAt        int64  `json:"createdAt"`
+	UpdatedAt        int64  `json:"updatedAt"`
+}
+
+// Balance is the balance of an asset served by the account endpoint
+type Balance struct {
+	Asset          string `json:"asset"`
+	Unlocked       string `json:"unlocked"`
+	LockedInOrders string `json:"lockedInOrders"`
+	CanDeposit     bool   `json:"canDeposit"`
+	CanWithdraw    bool   `json:"canWithdraw"`
+}
+
+// Kline is a candlestick served by the klines endpoint
+type Kline struct {
+	OpenTime       int64  `json:"openTime"`
+	CloseTime      int64  `json:"closeTime"`
+	OpenPrice      string `json:"openPrice"`
+	HighPrice      string `json:"highPrice"`
+	LowPrice       string `json:"lowPrice"`
+	ClosePrice     string `json:"closePrice"`
+	Volume         string `json:"volume"`
+	QuoteVolume    string `json:"quoteVolume"`
+	NumberOfTrades int    `json:"numberOfTrades"`
+}
+
+// Withdrawal is a withdrawal held by the server
+type Withdrawal struct {
+	ID        string `json:"id"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	MinerFee  string `json:"minerFee"`
+	Address   string `json:"address"`
+	Memo      string `json:"memo,omitempty"`
+	TxHash    string `json:"txHash"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// Deposit is a deposit held by the server
+type Deposit struct {
+	ID        string `json:"id"`
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	Address   string `json:"address"`
+	TxHash    string `json:"txHash"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// Request is a REST request received by the server
+type Request struct {
+	Method string
+	Path   string
+	// Params holds the query of GET requests and the JSON body of the others
+	Params map[string]interface{}
+	// APIKey is the user of the basic auth, empty for public endpoints
+	APIKey string
+}
+
+type injectedError struct {
+	status int
+	body   string
+}
+
+// Server is a fake Namebase exchange. Its state may be scripted
+// with the setters at any time, also while the client is running
+type Server struct {
+	// URL is the base URL of the REST endpoints
+	URL string
+	// WsURL is the base URL of the websocket streams
+	WsURL string
+
+	srv *httptest.Server
+
+	mu          sync.Mutex
+	apiKey      string
+	secret      string
+	symbols     []Symbol
+	depth       map[string]*Depth
+	prices      map[string]string
+	orders      map[int]*Order
+	nextID      int
+	balances    []Balance
+	makerFee    int
+	takerFee    int
+	noTrading   bool
+	klines      map[string][]Kline
+	address     string
+	memo        string
+	fee         string
+	withdrawals []Withdrawal
+	deposits    []Deposit
+	requests    []Request
+	errors      map[string][]injectedError
+	handlers    map[string]http.HandlerFunc
+	wsConns     map[string]map[*websocket.Conn]bool
+	upgrader    websocket.Upgrader
+}
+
+// NewServer starts a fake exchange listing HNSBTC, with an empty book and account
+func NewServer() *Server {
+	s := &Server{
+		symbols: []Symbol{{
+			Symbol:         "HNSBTC",
+			Status:         "TRADING",
+			BaseAsset:      "HNS",
+			BasePrecision:  6,
+			QuoteAsset:     "BTC",
+			QuotePrecision: 8,
+			OrderTypes:     []string{"LMT", "MKT"},
+		}},
+		depth:    make(map[string]*Depth),
+		prices:   make(map[string]string),
+		orders:   make(map[int]*Order),
+		nextID:   1,
+		klines:   make(map[string][]Kline),
+		address:  "hs1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc50gg5vw",
+		fee:      "0",
+		errors:   make(map[string][]injectedError),
+		handlers: make(map[string]http.HandlerFunc),
+		wsConns:  make(map[string]map[*websocket.Conn]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0/", s.serveREST)
+	mux.HandleFunc("/ws/v0/", s.serveWebsocket)
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	s.WsURL = "ws" + strings.TrimPrefix(s.srv.URL, "http")
+
+	return s
+}
+
+// Close shuts down the server and closes all connections,
+// clients reconnecting to streams then fail instead of lingering
+func (s *Server) Close() {
+	s.srv.Close()
+	s.DropConnections()
+}
+
+// SetCredentials makes signed endpoints require the key and secret,
+// by default any credentials are accepted
+func (s *Server) SetCredentials(key, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey, s.secret = key, secret
+}
+
+// SetSymbols replaces the listed trading pairs
+func (s *Server) SetSymbols(symbols ...Symbol) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = symbols
+}
+
+// SetDepth replaces the order book of symbol
+func (s *Server) SetDepth(symbol string, d Depth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depth[symbol] = &d
+}
+
+// SetPrice sets the latest price of symbol
+func (s *Server) SetPrice(symbol, price string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = price
+}
+
+// SetAccount replaces the fees and balances of the account
+func (s *Server) SetAccount(makerFee, takerFee int, balances ...Balance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.makerFee, s.takerFee, s.balances = makerFee, takerFee, balances
+}
+
+// SetCanTrade sets the canTrade flag of the account, true by default
+func (s *Server) SetCanTrade(canTrade bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noTrading = !canTrade
+}
+
+// SetKlines replaces the klines of symbol and interval
+func (s *Server) SetKlines(symbol, interval string, klines []Kline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.klines[symbol+"/"+interval] = klines
+}
+
+// SetDepositAddress sets the address returned by the deposit endpoint,
+// an empty address makes it fail
+func (s *Server) SetDepositAddress(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.address = address
+}
+
+// SetDepositMemo sets the memo returned with the deposit address
+func (s *Server) SetDepositMemo(memo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memo = memo
+}
+
+// SetWithdrawalFee sets the miner fee of new withdrawals
+func (s *Server) SetWithdrawalFee(fee string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fee = fee
+}
+
+// AddWithdrawal adds a withdrawal to the history, its ID is generated if empty
+func (s *Server) AddWithdrawal(w Withdrawal) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w.ID == "" {
+		w.ID = fmt.Sprintf("w%d", len(s.withdrawals)+1)
+	}
+	s.withdrawals = append(s.withdrawals, w)
+	return w.ID
+}
+
+// AddDeposit adds a deposit to the history, its ID is generated if empty
+func (s *Server) AddDeposit(d Deposit) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d.ID == "" {
+		d.ID = fmt.Sprintf("d%d", len(s.deposits)+1)
+	}
+	s.deposits = append(s.deposits, d)
+	return d.ID
+}
+
+// AddOrder adds an order as if it was placed before, and returns its ID
+func (s *Server) AddOrder(o Order) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o.OrderID = s.nextID
+	s.nextID++
+	s.orders[o.OrderID] = &o
+	return o.OrderID
+}
+
+// UpdateOrder changes an order, e.g. to fill it. It reports whether the order exists
+func (s *Server) UpdateOrder(id int, fn func(*Order)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if ok {
+		fn(o)
+		o.UpdatedAt = now()
+	}
+	return ok
+}
+
+// Order returns a copy of an order
+func (s *Server) Order(id int) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[id]
+	if !ok {
+		return Order{}, false
+	}
+	return *o, true
+}
+
+// Handle overrides the response of an endpoint, e.g. Handle("GET", "/api/v0/depth", h)
+func (s *Server) Handle(method, path string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method+" "+path] = h
+}
+
+// InjectError makes the next request to the endpoint fail with the HTTP status
+// and body. Errors injected several times are returned in turn
+func (s *Server) InjectError(method, path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := method + " " + path
+	s.errors[key] = append(s.errors[key], injectedError{status, body})
+}
+
+// InjectAPIError makes the next request to the endpoint return an exchange error
+// with the code and message, which comes with HTTP status 200 like the exchange does
+func (s *Server) InjectAPIError(method, path, code, message string) {
+	body, _ := json.Marshal(map[string]string{"code": code, "message": message})
+	s.InjectError(method, path, http.StatusOK, string(body))
+}
+
+// Requests returns the REST requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// PushDepth sends a message to the subscribers of the depth stream
+func (s *Server) PushDepth(msg interface{}) error {
+	return s.push("/ws/v0/ticker/depth", msg)
+}
+
+// PushTrade sends a message to the subscribers of the trades stream
+func (s *Server) PushTrade(msg interface{}) error {
+	return s.push("/ws/v0/stream/trades", msg)
+}
+
+// Subscribers returns the number of connections to the stream at path
+func (s *Server) Subscribers(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.wsConns[path])
+}
+
+// DropConnections closes all websocket connections, to test reconnection
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conns := range s.wsConns {
+		for c := range conns {
+			c.Close()
+			delete(conns, c)
+		}
+	}
+}
+
+func (s *Server) push(path string, msg interface{}) error {
+	data, ok := msg.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(msg); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.wsConns[path] {
+		if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
+			c.Close()
+			delete(s.wsConns[path], c)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ws/v0/ticker/depth" && r.URL.Path != "/ws/v0/stream/trades" {
+		http.NotFound(w, r)
+		return
+	}
+
+	c, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.wsConns[r.URL.Path] == nil {
+		s.wsConns[r.URL.Path] = make(map[*websocket.Conn]bool)
+	}
+	s.wsConns[r.URL.Path][c] = true
+	s.mu.Unlock()
+
+	// drain the connection until the client goes away
+	for {
+		if _, _, err := c.ReadMessage(); err != nil {
+			s.mu.Lock()
+			delete(s.wsConns[r.URL.Path], c)
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+	}
+}
+
+func (s *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	params := make(map[string]interface{})
+	if r.Method == http.MethodGet {
+		for k, v := range r.URL.Query() {
+			params[k] = v[0]
+		}
+	} else if body, _ := ioutil.ReadAll(r.Body); len(body) > 0 {
+		if err := json.Unmarshal(body, &params); err != nil {
+			writeError(w, "BAD_REQUEST", err.Error())
+			return
+		}
+	}
+
+	key, secret, signed := r.BasicAuth()
+	key = strings.TrimSpace(key)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Params: params,
+		APIKey: key,
+	})
+
+	endpoint := r.Method + " " + r.URL.Path
+	if injected := s.errors[endpoint]; len(injected) > 0 {
+		s.errors[endpoint] = injected[1:]
+		s.mu.Unlock()
+		w.WriteHeader(injected[0].status)
+		w.Write([]byte(injected[0].body))
+		return
+	}
+
+	if h, ok := s.handlers[endpoint]; ok {
+		s.mu.Unlock()
+		h(w, r)
+		return
+	}
+
+	if s.apiKey != "" && signed && (key != s.apiKey || secret != s.secret) {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+		writeError(w, "UNAUTHORIZED", "invalid api key")
+		return
+	}
+	defer s.mu.Unlock()
+
+	var result interface{}
+	var err error
+	switch endpoint {
+	case "GET /api/v0/info":
+		result = map[string]interface{}{
+			"timezone":   "UTC",
+			"serverTime": now(),
+			"symbols":    s.symbols,
+		}
+	case "GET /api/v0/depth":
+		result, err = s.getDepth(params)
+	case "GET /api/v0/ticker/price":
+		result, err = s.getPrice(params)
+	case "GET /api/v0/ticker/klines":
+		result, err = s.getKlines(params)
+	case "POST /api/v0/order":
+		result, err = s.placeOrder(params)
+	case "GET /api/v0/order":
+		result, err = s.getOrder(params)
+	case "DELETE /api/v0/order":
+		result, err = s.cancelOrder(params)
+	case "GET /api/v0/order/open":
+		result, err = s.openOrders(params)
+	case "GET /api/v0/account":
+		result = map[string]interface{}{
+			"makerFee": s.makerFee,
+			"takerFee": s.takerFee,
+			"canTrade": !s.noTrading,
+			"balances": s.balances,
+		}
+	case "POST /api/v0/deposit/address":
+		result = map[string]interface{}{
+			"address": s.address,
+			"memo":    s.memo,
+			"network": params["asset"],
+			"success": s.address != "",
+			"asset":   params["asset"],
+		}
+	case "POST /api/v0/withdraw":
+		result, err = s.withdrawal(params)
+	case "GET /api/v0/withdraw/history":
+		result = s.withdrawalHistory(params)
+	case "GET /api/v0/deposit/history":
+		result = s.depositHistory(params)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) getDepth(params map[string]interface{}) (interface{}, error) {
+	d := s.depth[str(params["symbol"])]
+	if d == nil {
+		d = &Depth{}
+	}
+
+	limit := 100
+	if l := int(intParam(params["limit"])); l > 0 {
+		limit = l
+	}
+
+	result := Depth{LastEventID: d.LastEventID, Bids: []Level{}, Asks: []Level{}}
+	for i := 0; i < len(d.Bids) && i < limit; i++ {
+		result.Bids = append(result.Bids, d.Bids[i])
+	}
+	for i := 0; i < len(d.Asks) && i < limit; i++ {
+		result.Asks = append(result.Asks, d.Asks[i])
+	}
+
+	return result, nil
+}
+
+func (s *Server) getPrice(params map[string]interface{}) (interface{}, error) {
+	price, ok := s.prices[str(params["symbol"])]
+	if !ok {
+		return nil, fmt.Errorf("no price of %s", params["symbol"])
+	}
+
+	return map[string]string{"price": price}, nil
+}
+
+func (s *Server) getKlines(params map[string]interface{}) (interface{}, error) {
+	all := s.klines[str(params["symbol"])+"/"+str(params["interval"])]
+
+	limit := 100
+	if l := int(intParam(params["limit"])); l > 0 {
+		limit = l
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	_, hasStart := params["startTime"]
+	_, hasEnd := params["endTime"]
+	start, end := intParam(params["startTime"]), intParam(params["endTime"])
+	klines := []Kline{}
+	for _, k := range all {
+		if hasStart && k.OpenTime < start || hasEnd && k.OpenTime > end {
+			continue
+		}
+		klines = append(klines, k)
+	}
+
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].OpenTime < klines[j].OpenTime
+	})
+
+	if len(klines) > limit {
+		if hasStart {
+			klines = klines[:limit]
+		} else {
+			klines = klines[len(klines)-limit:]
+		}
+	}
+
+	return klines, nil
+}
+
+func (s *Server) placeOrder(params map[string]interface{}) (interface{}, error) {
+	symbol := str(params["symbol"])
+	if !s.listed(symbol) {
+		return nil, fmt.Errorf("unknown symbol %s", symbol)
+	}
+
+	o := &Order{
+		OrderID:          s.nextID,
+		Symbol:           symbol,
+		Price:            str(params["price"]),
+		OriginalQuantity: str(params["quantity"]),
+		ExecutedQuantity: "0",
+		Status:           "NEW",
+		Type:             str(params["type"]),
+		Side:             str(params["side"]),
+		CreatedAt:        now(),
+	}
+	o.UpdatedAt = o.CreatedAt
+
+	if o.Side != "BUY" && o.Side != "SELL" {
+		return nil, fmt.Errorf("invalid side %s", o.Side)
+	}
+
+	switch o.Type {
+	case "LMT":
+	case "MKT":
+		// market orders are filled right away
+		o.Price = "0"
+		o.ExecutedQuantity = o.OriginalQuantity
+		o.Status = "FILLED"
+	default:
+		return nil, fmt.Errorf("invalid type %s", o.Type)
+	}
+
+	s.nextID++
+	s.orders[o.OrderID] = o
+
+	return o, nil
+}
+
+func (s *Server) findOrder(params map[string]interface{}) (*Order, error) {
+	o, ok := s.orders[int(intParam(params["orderId"]))]
+	if !ok || o.Symbol != "" && o.Symbol != str(params["symbol"]) {
+		return nil, fmt.Errorf("order %v not found", params["orderId"])
+	}
+
+	return o, nil
+}
+
+func (s *Server) getOrder(params map[string]interface{}) (interface{}, error) {
+	return s.findOrder(params)
+}
+
+func (s *Server) cancelOrder(params map[string]interface{}) (interface{}, error) {
+	o, err := s.findOrder(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Status != "NEW" && o.Status != "PARTIALLY_FILLED" {
+		return nil, fmt.Errorf("order %d is %s", o.OrderID, o.Status)
+	}
+
+	o.Status = "CANCELED"
+	o.UpdatedAt = now()
+
+	return o, nil
+}
+
+func (s *Server) openOrders(params map[string]interface{}) (interface{}, error) {
+	symbol := str(params["symbol"])
+	orders := []*Order{}
+	for _, o := range s.orders {
+		if (o.Status == "NEW" || o.Status == "PARTIALLY_FILLED") &&
+			(o.Symbol == "" || o.Symbol == symbol) {
+			orders = append(orders, o)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].OrderID < orders[j].OrderID
+	})
+
+	return orders, nil
+}
+
+func (s *Server) withdrawal(params map[string]interface{}) (interface{}, error) {
+	if str(params["address"]) == "" || str(params["amount"]) == "" {
+		return nil, fmt.Errorf("address and amount are required")
+	}
+
+	w := Withdrawal{
+		ID:        fmt.Sprintf("w%d", len(s.withdrawals)+1),
+		Asset:     str(params["asset"]),
+		Amount:    str(params["amount"]),
+		MinerFee:  s.fee,
+		Address:   str(params["address"]),
+		Memo:      str(params["memo"]),
+		Status:    "PENDING",
+		CreatedAt: now(),
+	}
+	s.withdrawals = append(s.withdrawals, w)
+
+	return struct {
+		Withdrawal
+		Success bool `json:"success"`
+	}{w, true}, nil
+}
+
+func (s *Server) withdrawalHistory(params map[string]interface{}) interface{} {
+	result := []Withdrawal{}
+	for _, w := range s.withdrawals {
+		if inHistory(params, w.Asset, w.CreatedAt) {
+			result = append(result, w)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+
+	start, end := historyPage(params, len(result))
+	return result[start:end]
+}
+
+func (s *Server) depositHistory(params map[string]interface{}) interface{} {
+	result := []Deposit{}
+	for _, d := range s.deposits {
+		if inHistory(params, d.Asset, d.CreatedAt) {
+			result = append(result, d)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+
+	start, end := historyPage(params, len(result))
+	return result[start:end]
+}
+
+// inHistory filters the history by the asset, startTime and endTime params
+func inHistory(params map[string]interface{}, asset string, createdAt int64) bool {
+	if a := str(params["asset"]); a != "" && a != asset {
+		return false
+	}
+
+	_, hasStart := params["startTime"]
+	_, hasEnd := params["endTime"]
+	return !(hasStart && createdAt < intParam(params["startTime"]) ||
+		hasEnd && createdAt > intParam(params["endTime"]))
+}
+
+// historyPage returns the bounds of the page of n records selected
+// by the offset and limit params, a page has at most 100 records
+func historyPage(params map[string]interface{}, n int) (int, int) {
+	limit := 100
+	if l := int(intParam(params["limit"])); l > 0 && l < limit {
+		limit = l
+	}
+
+	start := int(intParam(params["offset"]))
+	if start < 0 {
+		start = 0
+	}
+	if start > n {
+		start = n
+	}
+
+	end := start + limit
+	if end > n {
+		end = n
+	}
+
+	return start, end
+}
+
+func (s *Server) listed(symbol string) bool {
+	for _, sym := range s.symbols {
+		if sym.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, code, message string) {
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+func str(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// intParam reads an integer from a query string or a JSON number
+func intParam(v interface{}) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	}
+	return 0
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
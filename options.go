@@ -0,0 +1,55 @@
+package namebase
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRateLimit    = 10
+	defaultRateInterval = time.Second
+)
+
+// ClientOption customizes the client created by NewClient
+type ClientOption func(*Namebase)
+
+// WithRateLimit limits the client to n REST requests per interval,
+// a non-positive n disables rate limiting
+func WithRateLimit(n int, per time.Duration) ClientOption {
+	return func(nb *Namebase) {
+		nb.limiter = newRateLimiter(n, per)
+	}
+}
+
+// WithBaseURL sends REST requests to url instead of the exchange,
+// e.g. to a namebasetest.Server
+func WithBaseURL(url string) ClientOption {
+	return func(nb *Namebase) {
+		nb.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithWebsocketURL subscribes streams from url instead of the exchange
+func WithWebsocketURL(url string) ClientOption {
+	return func(nb *Namebase) {
+		nb.wsURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithHTTPClient sends REST requests with c, e.g. to set a timeout or a
+// transport recording or replaying them like namebasetest.Recorder
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(nb *Namebase) {
+		nb.httpClient = c
+	}
+}
+
+// WithStrictDecoding makes the client fail on orders with an unknown status,
+// type or side, instead of returning the raw value. It is meant for debugging
+// changes of the exchange API
+func WithStrictDecoding() ClientOption {
+	return func(nb *Namebase) {
+		nb.strictDecoding = true
+	}
+}
@@ -0,0 +1,273 @@
+package namebase
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PaperTrader simulates trading with the same methods as Namebase, matching
+// orders against the order book and trades of the exchange, so that strategies
+// can run on live market data without risking funds.
+//
+// Orders crossing the book are filled as taker at the book prices, limit orders
+// left in the book are filled as maker at their price when a trade or the book
+// reaches it. Fees are charged on the received asset.
+type PaperTrader struct {
+	// OnFill, if set, is called with every fill
+	OnFill func(PaperFill)
+
+	mu    sync.Mutex
+	books map[CurrencyPair]*Depth
+	ledger
+}
+
+// NewPaperTrader creates a PaperTrader holding the initial balances.
+// nb provides the symbol precisions and the market data of Run,
+// fees are in basis points like Account.MakerFee and Account.TakerFee
+func NewPaperTrader(nb *Namebase, balances map[Currency]decimal.Decimal,
+	makerFee, takerFee int) *PaperTrader {
+	return &PaperTrader{
+		books: make(map[CurrencyPair]*Depth),
+		ledger: newLedger(nb, balances, makerFee, takerFee, func() int64 {
+			return timeToMs(time.Now())
+		}),
+	}
+}
+
+// Run feeds the order book and trades of pair from the exchange into the simulation
+func (p *PaperTrader) Run(pair CurrencyPair) error {
+	chDepth, err := p.nb.SubDepth(pair)
+	if err != nil {
+		return err
+	}
+
+	chTrade, err := p.nb.SubTrades(pair)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range chDepth {
+			p.OnDepth(pair, d)
+		}
+	}()
+
+	go func() {
+		for t := range chTrade {
+			p.OnTrade(pair, t)
+		}
+	}()
+
+	return nil
+}
+
+// OnDepth updates the order book of pair,
+// and fills the resting orders which the book has crossed
+func (p *PaperTrader) OnDepth(pair CurrencyPair, d Depth) {
+	book := &Depth{
+		Ts:          d.Ts,
+		LastEventID: d.LastEventID,
+		Bids:        make([]DepthRecord, len(d.Bids)),
+		Asks:        make([]DepthRecord, len(d.Asks)),
+	}
+	copy(book.Bids, d.Bids)
+	copy(book.Asks, d.Asks)
+
+	// best prices first
+	sort.Slice(book.Bids, func(i, j int) bool { return book.Bids[i].Price.GreaterThan(book.Bids[j].Price) })
+	sort.Slice(book.Asks, func(i, j int) bool { return book.Asks[i].Price.LessThan(book.Asks[j].Price) })
+
+	p.mu.Lock()
+	p.books[pair] = book
+
+	var fills []PaperFill
+	for _, o := range p.openOrders(pair) {
+		levels := &book.Asks
+		if o.Side == SellOrder {
+			levels = &book.Bids
+		}
+
+		fills = append(fills, p.take(o, levels, true)...)
+	}
+	p.mu.Unlock()
+
+	p.emit(fills)
+}
+
+// OnTrade fills the resting orders of pair reached by the trade
+func (p *PaperTrader) OnTrade(pair CurrencyPair, t Trade) {
+	p.mu.Lock()
+
+	var fills []PaperFill
+	available := t.Quantity
+	for _, o := range p.openOrders(pair) {
+		if !available.IsPositive() {
+			break
+		}
+
+		if o.Side == BuyOrder && t.Price.GreaterThan(o.Price) ||
+			o.Side == SellOrder && t.Price.LessThan(o.Price) {
+			continue
+		}
+
+		qty := decimal.Min(available, o.RemainingQuantity())
+		available = available.Sub(qty)
+		fills = append(fills, p.fill(o, o.Price, qty, true))
+	}
+	p.mu.Unlock()
+
+	p.emit(fills)
+}
+
+// LimitBuy buy token at limited price
+func (p *PaperTrader) LimitBuy(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return p.place(OrderRequest{
+		Pair: pair, Side: BuyOrder, Type: OrderTypeLimit, Quantity: amount, Price: price,
+	})
+}
+
+// LimitSell sell token at limited price
+func (p *PaperTrader) LimitSell(amount, price decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return p.place(OrderRequest{
+		Pair: pair, Side: SellOrder, Type: OrderTypeLimit, Quantity: amount, Price: price,
+	})
+}
+
+// MarketBuy buy token at market price
+func (p *PaperTrader) MarketBuy(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return p.place(OrderRequest{
+		Pair: pair, Side: BuyOrder, Type: OrderTypeMarket, Quantity: amount,
+	})
+}
+
+// MarketSell sells token at market price
+func (p *PaperTrader) MarketSell(amount decimal.Decimal, pair CurrencyPair) (*Order, error) {
+	return p.place(OrderRequest{
+		Pair: pair, Side: SellOrder, Type: OrderTypeMarket, Quantity: amount,
+	})
+}
+
+// CancelOrder cancels an open order and unlocks its balance
+func (p *PaperTrader) CancelOrder(orderID int, pair CurrencyPair) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.cancel(orderID, pair); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetOrder queries order detail
+func (p *PaperTrader) GetOrder(orderID int, pair CurrencyPair) (*Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	o, err := p.order(orderID, pair)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.copy(), nil
+}
+
+// OpenOrders lists all open orders of a trading pair
+func (p *PaperTrader) OpenOrders(pair CurrencyPair) ([]Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return copyOrders(p.openOrders(pair)), nil
+}
+
+// GetAccount returns the simulated balances
+func (p *PaperTrader) GetAccount() (*Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.account(), nil
+}
+
+func (p *PaperTrader) place(req OrderRequest) (*Order, error) {
+	p.mu.Lock()
+
+	book, ok := p.books[req.Pair]
+	if !ok && req.Type == OrderTypeMarket {
+		p.mu.Unlock()
+		return nil, errors.New("no order book to match market order")
+	}
+
+	o, err := p.newOrder(req)
+	if err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	var fills []PaperFill
+	if book != nil {
+		levels := &book.Asks
+		if req.Side == SellOrder {
+			levels = &book.Bids
+		}
+		fills = p.take(o, levels, false)
+	}
+
+	// nothing is left to match the rest of market orders
+	if o.Type == OrderTypeMarket && o.IsOpen() {
+		p.expire(o)
+	}
+
+	result := o.copy()
+	p.mu.Unlock()
+
+	p.emit(fills)
+	return result, nil
+}
+
+// take fills the order against the levels of the opposite side of the book,
+// and removes the liquidity it has taken from them. Resting orders are
+// filled as maker at their own price, new orders as taker at the book prices
+func (p *PaperTrader) take(o *paperOrder, levels *[]DepthRecord, maker bool) []PaperFill {
+	var fills []PaperFill
+
+	for len(*levels) > 0 && o.IsOpen() {
+		level := &(*levels)[0]
+		if o.Type == OrderTypeLimit && (o.Side == BuyOrder && level.Price.GreaterThan(o.Price) ||
+			o.Side == SellOrder && level.Price.LessThan(o.Price)) {
+			break
+		}
+
+		price := level.Price
+		if maker {
+			price = o.Price
+		}
+
+		qty := decimal.Min(level.Amount, p.fillable(o, price))
+		if !qty.IsPositive() {
+			break
+		}
+
+		fills = append(fills, p.fill(o, price, qty, maker))
+
+		level.Amount = level.Amount.Sub(qty)
+		if !level.Amount.IsPositive() {
+			*levels = (*levels)[1:]
+		}
+	}
+
+	return fills
+}
+
+func (p *PaperTrader) emit(fills []PaperFill) {
+	if p.OnFill == nil {
+		return
+	}
+
+	for _, f := range fills {
+		p.OnFill(f)
+	}
+}
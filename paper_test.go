@@ -0,0 +1,77 @@
+package namebase
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPaperTrader(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	client := &Namebase{
+		symbolInfo: map[CurrencyPair]symbolInfo{
+			pair: {BasePrecision: 6, QuotePrecision: 8},
+		},
+	}
+
+	var fills []PaperFill
+	p := NewPaperTrader(client, map[Currency]decimal.Decimal{
+		"BTC": decimal.NewFromFloat(0.01),
+	}, 10, 20)
+	p.OnFill = func(f PaperFill) { fills = append(fills, f) }
+
+	p.OnDepth(pair, Depth{
+		Asks: []DepthRecord{
+			{decimal.RequireFromString("0.000012"), decimal.New(100, 0)},
+			{decimal.RequireFromString("0.000011"), decimal.New(100, 0)},
+		},
+		Bids: []DepthRecord{{decimal.RequireFromString("0.000009"), decimal.New(100, 0)}},
+	})
+
+	// takes the best ask, then rests at 0.0000115
+	o, err := p.LimitBuy(decimal.New(150, 0), decimal.RequireFromString("0.0000115"), pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Status != OrderStatusPartiallyFilled || o.ExecutedQuantity.String() != "100" {
+		t.Errorf("unexpected order: %+v", o)
+	}
+
+	p.OnTrade(pair, Trade{Price: decimal.RequireFromString("0.000011"), Quantity: decimal.New(80, 0)})
+
+	if o, _ = p.GetOrder(o.OrderID, pair); o.Status != OrderStatusFilled {
+		t.Errorf("unexpected order: %+v", o)
+	}
+
+	if len(fills) != 2 || fills[0].IsMaker || !fills[1].IsMaker {
+		t.Fatalf("unexpected fills: %+v", fills)
+	}
+
+	acct, _ := p.GetAccount()
+	hns, _ := acct.Balance("HNS")
+	btc, _ := acct.Balance("BTC")
+
+	// 100 - 0.2% taker fee + 50 - 0.1% maker fee
+	if hns.Unlocked.String() != "149.75" {
+		t.Errorf("unexpected HNS balance: %+v", hns)
+	}
+
+	// 0.01 - 100 * 0.000011 - 50 * 0.0000115
+	if btc.Unlocked.String() != "0.008325" || !btc.Locked.IsZero() {
+		t.Errorf("unexpected BTC balance: %+v", btc)
+	}
+
+	if _, err := p.MarketSell(decimal.New(200, 0), pair); err == nil {
+		t.Error("expected insufficient balance")
+	}
+
+	o, err = p.MarketSell(decimal.New(149, 0), pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Status != OrderStatusExpired || o.ExecutedQuantity.String() != "100" {
+		t.Errorf("unexpected market order: %+v", o)
+	}
+}
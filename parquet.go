@@ -0,0 +1,273 @@
+package namebase
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// A minimal parquet writer: a single row group of required flat columns,
+// each stored as one uncompressed data page with plain encoding.
+// See https://github.com/apache/parquet-format
+
+// parquet physical types
+const (
+	parquetBoolean   int32 = 0
+	parquetInt32     int32 = 1
+	parquetInt64     int32 = 2
+	parquetByteArray int32 = 6
+)
+
+// parquet converted types, parquetNone means no converted type
+const (
+	parquetNone            int32 = -1
+	parquetUTF8            int32 = 0
+	parquetTimestampMillis int32 = 9
+)
+
+const (
+	parquetMagic        = "PAR1"
+	parquetRequired     = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// parquetColumn is a column with its values already plain encoded
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+	data      []byte
+	bits      int // number of booleans in data
+}
+
+func newParquetColumn(name string, typ, converted int32) *parquetColumn {
+	return &parquetColumn{name: name, typ: typ, converted: converted}
+}
+
+func (c *parquetColumn) appendInt32(v int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(v))
+	c.data = append(c.data, b[:]...)
+}
+
+func (c *parquetColumn) appendInt64(v int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	c.data = append(c.data, b[:]...)
+}
+
+func (c *parquetColumn) appendString(s string) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(s)))
+	c.data = append(c.data, b[:]...)
+	c.data = append(c.data, s...)
+}
+
+func (c *parquetColumn) appendBool(v bool) {
+	if c.bits%8 == 0 {
+		c.data = append(c.data, 0)
+	}
+	if v {
+		c.data[len(c.data)-1] |= 1 << uint(c.bits%8)
+	}
+	c.bits++
+}
+
+// countingWriter keeps track of the offset in the file
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// writeParquet writes the columns, which all hold numRows values, as a parquet file
+func writeParquet(w io.Writer, columns []*parquetColumn, numRows int) error {
+	cw := &countingWriter{w: w}
+	cw.Write([]byte(parquetMagic))
+
+	offsets := make([]int64, len(columns))
+	sizes := make([]int64, len(columns))
+	for i, c := range columns {
+		header := &thriftWriter{}
+		header.beginStruct()
+		header.i32Field(1, parquetDataPage)
+		header.i32Field(2, int32(len(c.data)))
+		header.i32Field(3, int32(len(c.data)))
+		header.structField(5)
+		header.i32Field(1, int32(numRows))
+		header.i32Field(2, parquetPlain)
+		header.i32Field(3, parquetRLE)
+		header.i32Field(4, parquetRLE)
+		header.endStruct()
+		header.endStruct()
+
+		offsets[i] = cw.n
+		sizes[i] = int64(len(header.buf) + len(c.data))
+		cw.Write(header.buf)
+		cw.Write(c.data)
+	}
+
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+
+	meta := &thriftWriter{}
+	meta.beginStruct()
+	meta.i32Field(1, 1)
+
+	meta.listField(2, thriftStruct, len(columns)+1)
+	meta.beginStruct()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(columns)))
+	meta.endStruct()
+	for _, c := range columns {
+		meta.beginStruct()
+		meta.i32Field(1, c.typ)
+		meta.i32Field(3, parquetRequired)
+		meta.stringField(4, c.name)
+		if c.converted != parquetNone {
+			meta.i32Field(6, c.converted)
+		}
+		meta.endStruct()
+	}
+
+	meta.i64Field(3, int64(numRows))
+
+	meta.listField(4, thriftStruct, 1)
+	meta.beginStruct()
+	meta.listField(1, thriftStruct, len(columns))
+	for i, c := range columns {
+		meta.beginStruct()
+		meta.i64Field(2, offsets[i])
+		meta.structField(3)
+		meta.i32Field(1, c.typ)
+		meta.listField(2, thriftI32, 1)
+		meta.i32(parquetPlain)
+		meta.listField(3, thriftBinary, 1)
+		meta.binary(c.name)
+		meta.i32Field(4, parquetUncompressed)
+		meta.i64Field(5, int64(numRows))
+		meta.i64Field(6, sizes[i])
+		meta.i64Field(7, sizes[i])
+		meta.i64Field(9, offsets[i])
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64Field(2, total)
+	meta.i64Field(3, int64(numRows))
+	meta.endStruct()
+
+	meta.stringField(6, "github.com/sniperem/namebase")
+	meta.endStruct()
+
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(meta.buf)))
+	cw.Write(meta.buf)
+	cw.Write(size[:])
+	cw.Write([]byte(parquetMagic))
+
+	return cw.err
+}
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the thrift compact protocol,
+// which parquet uses for its metadata
+type thriftWriter struct {
+	buf     []byte
+	lastIDs []int16
+	lastID  int16
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastIDs = append(t.lastIDs, t.lastID)
+	t.lastID = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf = append(t.buf, 0)
+	t.lastID = t.lastIDs[len(t.lastIDs)-1]
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf = append(t.buf, b[:n]...)
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) stringField(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+// structField starts a nested struct, which must be ended with endStruct
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+// listField starts a list, which is followed by size elements
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xf0|elemType)
+		t.uvarint(uint64(size))
+	}
+}
@@ -0,0 +1,210 @@
+package namebase
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// thriftReader decodes the thrift compact protocol into structs of
+// map[int16]interface{}, lists of []interface{}, int64, string and bool,
+// so that the tests can read back what thriftWriter encodes
+type thriftReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (t *thriftReader) byte() byte {
+	if t.pos >= len(t.buf) {
+		t.err = errors.New("thrift: unexpected end of data")
+		return 0
+	}
+
+	b := t.buf[t.pos]
+	t.pos++
+	return b
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	if t.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(t.buf[t.pos:])
+	if n <= 0 {
+		t.err = errors.New("thrift: invalid varint")
+		return 0
+	}
+
+	t.pos += n
+	return v
+}
+
+func (t *thriftReader) varint() int64 {
+	v := t.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		return int64(int8(t.byte()))
+	case thriftI32, thriftI64, 4:
+		return t.varint()
+	case thriftBinary:
+		n := int(t.uvarint())
+		if t.err != nil || t.pos+n > len(t.buf) {
+			t.err = errors.New("thrift: binary out of range")
+			return ""
+		}
+		s := string(t.buf[t.pos : t.pos+n])
+		t.pos += n
+		return s
+	case thriftList:
+		header := t.byte()
+		size, elemType := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(t.uvarint())
+		}
+
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size && t.err == nil; i++ {
+			if elemType == 1 || elemType == 2 {
+				list = append(list, t.byte() == 1)
+			} else {
+				list = append(list, t.value(elemType))
+			}
+		}
+		return list
+	case thriftStruct:
+		return t.structure()
+	default:
+		t.err = fmt.Errorf("thrift: unsupported type %d", typ)
+		return nil
+	}
+}
+
+func (t *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+
+	for t.err == nil {
+		header := t.byte()
+		if header == 0 {
+			break
+		}
+
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(t.varint())
+		}
+		fields[id] = t.value(header & 0x0f)
+	}
+
+	return fields
+}
+
+// readParquet reads back the files of writeParquet, and returns the values
+// of each column by name: int64 for INT32 and INT64, string and bool
+func readParquet(data []byte) (map[string][]interface{}, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, errors.New("missing parquet magic")
+	}
+
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footer <= 0 || footer > len(data)-12 {
+		return nil, fmt.Errorf("invalid footer length: %d", footer)
+	}
+
+	r := &thriftReader{buf: data[len(data)-8-footer : len(data)-8]}
+	meta := r.structure()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	numRows, _ := meta[3].(int64)
+	rowGroups, _ := meta[4].([]interface{})
+	if len(rowGroups) != 1 {
+		return nil, fmt.Errorf("expected 1 row group, got %d", len(rowGroups))
+	}
+
+	columns := make(map[string][]interface{})
+	chunks, _ := rowGroups[0].(map[int16]interface{})[1].([]interface{})
+	for _, chunk := range chunks {
+		cm, _ := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+		typ, _ := cm[1].(int64)
+		path, _ := cm[3].([]interface{})
+		numValues, _ := cm[5].(int64)
+		offset, _ := cm[9].(int64)
+		if len(path) != 1 || numValues != numRows || offset <= 0 || int(offset) >= len(data) {
+			return nil, fmt.Errorf("invalid column chunk: %v", cm)
+		}
+		name := path[0].(string)
+
+		pr := &thriftReader{buf: data[offset:]}
+		page := pr.structure()
+		if pr.err != nil {
+			return nil, pr.err
+		}
+
+		size, _ := page[3].(int64)
+		start := int(offset) + pr.pos
+		if start+int(size) > len(data) {
+			return nil, fmt.Errorf("column %s: page out of range", name)
+		}
+
+		values, err := decodePlain(int32(typ), data[start:start+int(size)], int(numRows))
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", name, err)
+		}
+		columns[name] = values
+	}
+
+	return columns, nil
+}
+
+// decodePlain decodes n plain encoded values of the physical type typ
+func decodePlain(typ int32, data []byte, n int) ([]interface{}, error) {
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		switch typ {
+		case parquetBoolean:
+			if i/8 >= len(data) {
+				return nil, errors.New("short data")
+			}
+			values = append(values, data[i/8]&(1<<uint(i%8)) != 0)
+		case parquetInt32:
+			if len(data) < 4 {
+				return nil, errors.New("short data")
+			}
+			values = append(values, int64(int32(binary.LittleEndian.Uint32(data))))
+			data = data[4:]
+		case parquetInt64:
+			if len(data) < 8 {
+				return nil, errors.New("short data")
+			}
+			values = append(values, int64(binary.LittleEndian.Uint64(data)))
+			data = data[8:]
+		case parquetByteArray:
+			if len(data) < 4 {
+				return nil, errors.New("short data")
+			}
+			l := int(binary.LittleEndian.Uint32(data))
+			if len(data) < 4+l {
+				return nil, errors.New("short data")
+			}
+			values = append(values, string(data[4:4+l]))
+			data = data[4+l:]
+		default:
+			return nil, fmt.Errorf("unsupported type %d", typ)
+		}
+	}
+
+	return values, nil
+}
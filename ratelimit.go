@@ -0,0 +1,51 @@
+package namebase
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests so that no more than burst requests
+// are sent within any window of burst*interval
+type rateLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	tolerance time.Duration
+	next      time.Time
+}
+
+func newRateLimiter(n int, per time.Duration) *rateLimiter {
+	if n <= 0 || per <= 0 {
+		return nil
+	}
+
+	interval := per / time.Duration(n)
+	return &rateLimiter{
+		interval:  interval,
+		tolerance: interval * time.Duration(n-1),
+	}
+}
+
+// Wait blocks until a request may be sent and returns how long it waited
+func (l *rateLimiter) Wait() time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	allowAt := l.next.Add(-l.tolerance)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := allowAt.Sub(now)
+	if wait <= 0 {
+		return 0
+	}
+
+	time.Sleep(wait)
+	return wait
+}
@@ -0,0 +1,402 @@
+package namebase
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Streams of recorded messages
+const (
+	RecordDepth         = "depth"
+	RecordDepthSnapshot = "depthSnapshot"
+	RecordTrades        = "trades"
+	RecordKlines        = "klines"
+)
+
+const (
+	defaultRecordMaxFileSize      = 64 << 20
+	defaultRecordMaxFileAge       = time.Hour
+	defaultRecordSnapshotInterval = time.Minute
+	defaultRecordSnapshotSize     = 50
+
+	recordFilePrefix  = "namebase-"
+	recordFileSuffix  = ".jsonl.gz"
+	recordFileTimeFmt = "20060102T150405.000000000Z"
+)
+
+// Record is a message captured by Recorder
+type Record struct {
+	// Time is when the message was received, in milliseconds
+	Time   int64  `json:"time"`
+	Stream string `json:"stream"`
+	Symbol string `json:"symbol"`
+	// Data is the raw websocket message or REST response
+	Data json.RawMessage `json:"data"`
+}
+
+// RecorderOptions customizes a Recorder
+type RecorderOptions struct {
+	// Dir is where the files are written, it is created if missing
+	Dir string
+	// MaxFileSize rotates the file after so many uncompressed bytes, 64MB by default
+	MaxFileSize int64
+	// MaxFileAge rotates the file after the duration, one hour by default
+	MaxFileAge time.Duration
+	// SnapshotInterval is how often the order book is queried
+	// while recording depth, one minute by default
+	SnapshotInterval time.Duration
+	// SnapshotSize is the number of levels of order book snapshots, 50 by default
+	SnapshotSize int
+}
+
+// Recorder writes raw stream messages and order book snapshots to
+// gzip compressed JSON lines files, one Record per line.
+// A new file is started when the current one is too large or too old
+type Recorder struct {
+	nb   *Namebase
+	opts RecorderOptions
+
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	written int64
+	opened  time.Time
+	// stopping is set by Close, and closed once the file is closed
+	stopping bool
+	closed   bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder writing to opts.Dir
+func NewRecorder(nb *Namebase, opts RecorderOptions) (*Recorder, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("recording dir is required")
+	}
+
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = defaultRecordMaxFileSize
+	}
+
+	if opts.MaxFileAge <= 0 {
+		opts.MaxFileAge = defaultRecordMaxFileAge
+	}
+
+	if opts.SnapshotInterval <= 0 {
+		opts.SnapshotInterval = defaultRecordSnapshotInterval
+	}
+
+	if opts.SnapshotSize <= 0 {
+		opts.SnapshotSize = defaultRecordSnapshotSize
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		nb:   nb,
+		opts: opts,
+		done: make(chan struct{}),
+	}, nil
+}
+
+// RecordDepth records the order book diffs of pair, along with a snapshot
+// on every (re)connection and every SnapshotInterval
+func (r *Recorder) RecordDepth(pair CurrencyPair) error {
+	err := r.nb.stream(depthStreamPath, pair, r.done, &r.wg, func() error {
+		return r.recordSnapshot(pair)
+	}, func(data []byte) {
+		r.record(RecordDepth, pair, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.every(r.opts.SnapshotInterval, func() {
+		if err := r.recordSnapshot(pair); err != nil {
+			r.nb.log().Error("failed to record depth snapshot", "endpoint", depthStreamPath,
+				"pair", pair, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// RecordTrades records the trades of pair
+func (r *Recorder) RecordTrades(pair CurrencyPair) error {
+	return r.nb.stream(tradesStreamPath, pair, r.done, &r.wg, nil, func(data []byte) {
+		r.record(RecordTrades, pair, data)
+	})
+}
+
+// RecordKlines polls the latest klines of pair every period and records them,
+// the client has no kline stream to subscribe
+func (r *Recorder) RecordKlines(pair CurrencyPair, interval KlineInterval, period time.Duration) error {
+	if period <= 0 {
+		return errors.New("period must be positive")
+	}
+
+	poll := func() {
+		params := make(map[string]interface{})
+		params["symbol"] = pair.String()
+		params["interval"] = interval
+		// the previous kline is included to capture its final state
+		params["limit"] = 2
+
+		data, err := r.nb.do(http.MethodGet, "/api/v0/ticker/klines", params, false)
+		if err != nil {
+			r.nb.log().Error("failed to record klines", "endpoint", "/api/v0/ticker/klines",
+				"pair", pair, "error", err)
+			return
+		}
+		r.record(RecordKlines, pair, data)
+	}
+
+	poll()
+	r.every(period, poll)
+
+	return nil
+}
+
+// Close stops recording and flushes the current file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if r.stopping {
+		r.mu.Unlock()
+		return nil
+	}
+	r.stopping = true
+	r.mu.Unlock()
+
+	// streams and pollers are stopped before the file,
+	// so that none of them writes to a closed recorder
+	close(r.done)
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return r.closeFile()
+}
+
+func (r *Recorder) every(period time.Duration, fn func()) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+func (r *Recorder) recordSnapshot(pair CurrencyPair) error {
+	data, err := r.nb.rawDepth(pair, r.opts.SnapshotSize)
+	if err != nil {
+		return err
+	}
+
+	r.record(RecordDepthSnapshot, pair, data)
+	return nil
+}
+
+func (r *Recorder) record(stream string, pair CurrencyPair, data []byte) {
+	if !json.Valid(data) {
+		data, _ = json.Marshal(string(data))
+	}
+
+	rec := Record{
+		Time:   timeToMs(time.Now()),
+		Stream: stream,
+		Symbol: pair.String(),
+		Data:   data,
+	}
+
+	if err := r.Write(rec); err != nil {
+		r.nb.log().Error("failed to write record", "stream", stream, "pair", pair, "error", err)
+	}
+}
+
+// Write appends rec to the current file
+func (r *Recorder) Write(rec Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return errors.New("recorder is closed")
+	}
+
+	if r.file != nil && (r.written >= r.opts.MaxFileSize ||
+		time.Since(r.opened) >= r.opts.MaxFileAge) {
+		if err := r.closeFile(); err != nil {
+			return err
+		}
+	}
+
+	if r.file == nil {
+		if err := r.openFile(); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	n, err := r.gz.Write(append(line, '\n'))
+	r.written += int64(n)
+	if err != nil {
+		return err
+	}
+
+	// keep the file readable up to the last record if the process dies
+	return r.gz.Flush()
+}
+
+func (r *Recorder) openFile() error {
+	r.opened = time.Now()
+	name := recordFilePrefix + r.opened.UTC().Format(recordFileTimeFmt) + recordFileSuffix
+
+	f, err := os.OpenFile(filepath.Join(r.opts.Dir, name),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.file = f
+	r.gz = gzip.NewWriter(f)
+	r.written = 0
+
+	return nil
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	gzErr := r.gz.Close()
+	err := r.file.Close()
+	r.file, r.gz = nil, nil
+
+	if gzErr != nil {
+		return fmt.Errorf("failed to close recording: %v", gzErr)
+	}
+
+	return err
+}
+
+// RecordReader reads the records written by Recorder in order
+type RecordReader struct {
+	files []string
+	file  *os.File
+	gz    *gzip.Reader
+	dec   *json.Decoder
+}
+
+// OpenRecording opens all recording files in dir,
+// which are read in the order they were written
+func OpenRecording(dir string) (*RecordReader, error) {
+	files, err := filepath.Glob(filepath.Join(dir, recordFilePrefix+"*"+recordFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	// file names start with a fixed width timestamp
+	sort.Strings(files)
+
+	return &RecordReader{files: files}, nil
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (rr *RecordReader) Next() (*Record, error) {
+	for {
+		if rr.dec == nil {
+			if len(rr.files) == 0 {
+				return nil, io.EOF
+			}
+
+			if err := rr.openNext(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		rec := &Record{}
+		err := rr.dec.Decode(rec)
+		if err == nil {
+			return rec, nil
+		}
+
+		// a file of a process which died before closing it has no gzip
+		// trailer and may end in the middle of a record, it is read up
+		// to the last complete record
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("failed to read %s: %v", rr.file.Name(), err)
+		}
+
+		if err := rr.closeFile(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close closes the file being read
+func (rr *RecordReader) Close() error {
+	rr.files = nil
+	return rr.closeFile()
+}
+
+func (rr *RecordReader) openNext() error {
+	f, err := os.Open(rr.files[0])
+	if err != nil {
+		return err
+	}
+	rr.files = rr.files[1:]
+
+	gz, err := gzip.NewReader(f)
+	if err == io.EOF {
+		// the process died before writing the first record
+		return f.Close()
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to read %s: %v", f.Name(), err)
+	}
+
+	rr.file, rr.gz, rr.dec = f, gz, json.NewDecoder(gz)
+	return nil
+}
+
+func (rr *RecordReader) closeFile() error {
+	if rr.file == nil {
+		return nil
+	}
+
+	rr.gz.Close()
+	err := rr.file.Close()
+	rr.file, rr.gz, rr.dec = nil, nil, nil
+
+	return err
+}
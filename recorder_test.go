@@ -0,0 +1,232 @@
+package namebase
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorderRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewRecorder(nil, RecorderOptions{Dir: dir, MaxFileSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := NewCurrencyPair("hns", "btc")
+	for _, msg := range []string{`{"lastEventId":1}`, `{"lastEventId":2}`, `not json`} {
+		r.record(RecordDepth, pair, []byte(msg))
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := OpenRecording(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rr.Close()
+
+	if len(rr.files) != 3 {
+		t.Errorf("expected 3 files, got %d", len(rr.files))
+	}
+
+	expected := []string{`{"lastEventId":1}`, `{"lastEventId":2}`, `"not json"`}
+	for i := 0; ; i++ {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			if i != len(expected) {
+				t.Errorf("expected %d records, got %d", len(expected), i)
+			}
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(rec.Data) != expected[i] || rec.Symbol != "HNSBTC" || rec.Stream != RecordDepth {
+			t.Errorf("unexpected record %d: %+v", i, rec)
+		}
+	}
+}
+
+func TestReplayDepth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewRecorder(nil, RecorderOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := NewCurrencyPair("hns", "btc")
+	for _, rec := range []struct {
+		stream, data string
+	}{
+		{RecordDepth, `{"firstEventId":1,"lastEventId":1,"bids":[["0.9","1"]]}`},
+		{RecordDepthSnapshot, `{"lastEventId":5,"bids":[["1.0","10"],["0.8","5"]],"asks":[["1.1","3"]]}`},
+		{RecordDepth, `{"eventTime":7,"firstEventId":6,"lastEventId":7,"bids":[["0.9","2"],["1.0","0"]]}`},
+	} {
+		r.record(rec.stream, pair, []byte(rec.data))
+	}
+	r.Close()
+
+	replayer := NewReplayer(dir, pair)
+	replayer.Speed = 0
+	ch, err := replayer.SubDepth()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var depths []Depth
+	for d := range ch {
+		depths = append(depths, d)
+	}
+
+	if err := replayer.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(depths) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(depths))
+	}
+
+	d := depths[0]
+	if d.Ts != 7 || d.LastEventID != 7 || len(d.Bids) != 2 ||
+		d.Bids[0].Price.String() != "0.9" || d.Bids[1].Price.String() != "0.8" {
+		t.Errorf("unexpected book: %+v", d)
+	}
+}
+
+func TestReplayDepthGap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewRecorder(nil, RecorderOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := NewCurrencyPair("hns", "btc")
+	for _, rec := range []struct {
+		stream, data string
+	}{
+		{RecordDepthSnapshot, `{"lastEventId":5,"bids":[["1.0","10"]]}`},
+		{RecordDepth, `{"firstEventId":4,"lastEventId":6,"bids":[["0.9","2"]]}`},
+		// event 7 is missing
+		{RecordDepth, `{"firstEventId":8,"lastEventId":8,"bids":[["0.8","1"]]}`},
+		{RecordDepthSnapshot, `{"lastEventId":9,"bids":[["1.0","10"],["0.7","4"]]}`},
+		{RecordDepth, `{"firstEventId":10,"lastEventId":10,"bids":[["0.6","3"]]}`},
+	} {
+		r.record(rec.stream, pair, []byte(rec.data))
+	}
+	r.Close()
+
+	replay := func(resync bool) ([]Depth, error) {
+		replayer := NewReplayer(dir, pair)
+		replayer.Speed = 0
+		replayer.Resync = resync
+		ch, err := replayer.SubDepth()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var depths []Depth
+		for d := range ch {
+			depths = append(depths, d)
+		}
+
+		return depths, replayer.Err()
+	}
+
+	depths, err := replay(false)
+	if !errors.Is(err, ErrDepthGap) {
+		t.Errorf("expected gap, got %v", err)
+	}
+	if len(depths) != 1 || depths[0].LastEventID != 6 || len(depths[0].Bids) != 2 {
+		t.Errorf("unexpected books: %+v", depths)
+	}
+
+	depths, err = replay(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depths) != 2 || depths[1].LastEventID != 10 || len(depths[1].Bids) != 3 ||
+		depths[1].Bids[1].Price.String() != "0.7" {
+		t.Errorf("unexpected books: %+v", depths)
+	}
+}
+
+func TestReadUnclosedRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namebase-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the process died right after creating the file
+	empty := filepath.Join(dir, recordFilePrefix+"20000101T000000.000000000Z"+recordFileSuffix)
+	if err := ioutil.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pair := NewCurrencyPair("hns", "btc")
+	crashed, err := NewRecorder(nil, RecorderOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed.record(RecordDepth, pair, []byte(`{"lastEventId":1}`))
+	crashed.record(RecordDepth, pair, []byte(`{"lastEventId":2}`))
+	// the process dies without closing the gzip stream
+	crashed.file.Close()
+
+	// the files are named after the time they are opened
+	time.Sleep(time.Millisecond)
+	r, err := NewRecorder(nil, RecorderOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.record(RecordDepth, pair, []byte(`{"lastEventId":3}`))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := OpenRecording(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rr.Close()
+
+	var got []string
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(rec.Data))
+	}
+
+	if len(got) != 3 || got[2] != `{"lastEventId":3}` {
+		t.Errorf("unexpected records: %v", got)
+	}
+}
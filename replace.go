@@ -0,0 +1,78 @@
+package namebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	cancelConfirmRetries  = 5
+	cancelConfirmInterval = 200 * time.Millisecond
+)
+
+// ReplaceOrder cancels the limit order, confirms how much of it has been executed
+// and places a new limit order at newPrice for the part of newQty that is not yet executed.
+// It returns the final state of the canceled order and the replacement order,
+// which is nil if the old order has already executed newQty or more.
+func (nb *Namebase) ReplaceOrder(orderID int, pair CurrencyPair,
+	newQty, newPrice decimal.Decimal) (old *Order, replacement *Order, err error) {
+	old, err = nb.cancelAndConfirm(context.Background(), orderID, pair)
+	if err != nil {
+		return old, nil, err
+	}
+
+	remaining := newQty.Sub(old.ExecutedQuantity)
+	if !remaining.IsPositive() {
+		return old, nil, nil
+	}
+
+	if old.Side != BuyOrder && old.Side != SellOrder {
+		return old, nil, fmt.Errorf("unknown side of order %d: %s", orderID, old.Side)
+	}
+
+	replacement, err = nb.placeOrder(remaining, newPrice, pair, OrderTypeLimit, old.Side)
+	if err != nil {
+		return old, nil, err
+	}
+
+	return old, replacement, nil
+}
+
+// cancelAndConfirm cancels the order and queries it until it is no longer open.
+// The cancel may race a fill, so the exchange is the only source of truth
+// about how much has been executed. Waiting between queries stops when ctx is done
+func (nb *Namebase) cancelAndConfirm(ctx context.Context, orderID int,
+	pair CurrencyPair) (*Order, error) {
+	_, cancelErr := nb.CancelOrder(orderID, pair)
+
+	for i := 0; ; i++ {
+		o, err := nb.GetOrder(orderID, pair)
+		if err != nil {
+			return nil, err
+		}
+
+		if !o.IsOpen() {
+			return o, nil
+		}
+
+		if cancelErr != nil {
+			return o, cancelErr
+		}
+
+		if i == cancelConfirmRetries {
+			return o, errors.New("order is still open after cancel")
+		}
+
+		timer := time.NewTimer(cancelConfirmInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return o, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
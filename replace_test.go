@@ -0,0 +1,68 @@
+package namebase
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+func TestReplaceOrder(t *testing.T) {
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "PARTIALLY_FILLED",
+		Type: "LMT", Side: "BUY", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "30"})
+
+	old, replacement, err := nb.ReplaceOrder(id, NewCurrencyPair("hns", "btc"),
+		decimal.NewFromFloat(100), decimal.NewFromFloat(0.000011))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if old.Status != OrderStatusCanceled || old.ExecutedQuantity.String() != "30" {
+		t.Errorf("unexpected old order: %+v", old)
+	}
+
+	if replacement == nil || replacement.OriginalQuantity.String() != "70" ||
+		replacement.Side != BuyOrder || replacement.Price.String() != "0.000011" {
+		t.Errorf("unexpected replacement: %+v", replacement)
+	}
+}
+
+func TestReplaceOrderExecuted(t *testing.T) {
+	pair := NewCurrencyPair("hns", "btc")
+	for _, tc := range []struct {
+		name, status, executed string
+		expected               OrderStatus
+	}{
+		// the cancel fails, the order is filled already
+		{"filled", "FILLED", "100", OrderStatusFilled},
+		// more than the new quantity has been executed
+		{"partially filled", "PARTIALLY_FILLED", "60", OrderStatusCanceled},
+	} {
+		id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: tc.status,
+			Type: "LMT", Side: "SELL", Price: "0.00001", OriginalQuantity: "100",
+			ExecutedQuantity: tc.executed})
+		before := len(srv.Requests())
+
+		old, replacement, err := nb.ReplaceOrder(id, pair,
+			decimal.NewFromFloat(50), decimal.NewFromFloat(0.000011))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		if old.Status != tc.expected || old.ExecutedQuantity.String() != tc.executed {
+			t.Errorf("%s: unexpected old order: %+v", tc.name, old)
+		}
+
+		if replacement != nil {
+			t.Errorf("%s: unexpected replacement: %+v", tc.name, replacement)
+		}
+
+		for _, r := range srv.Requests()[before:] {
+			if r.Method == "POST" {
+				t.Errorf("%s: an order has been placed", tc.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package namebase
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// Replayer replays the order book of a pair recorded by Recorder, applying
+// the diffs to the snapshots with the same logic as SubDepth
+type Replayer struct {
+	// Speed scales the pace of the recording, 2 replays twice as fast,
+	// and 0 replays as fast as the consumer reads
+	Speed float64
+	// Resync resets the book on every snapshot, as SubDepth does after reconnecting,
+	// and skips the diffs after a gap until the next snapshot.
+	// Otherwise only the first snapshot is used, so that errors in the
+	// local book keep showing, and a gap stops the replay with ErrDepthGap
+	Resync bool
+
+	dir  string
+	pair CurrencyPair
+	err  error
+}
+
+// NewReplayer creates a Replayer of the recording in dir,
+// which replays at the original speed
+func NewReplayer(dir string, pair CurrencyPair) *Replayer {
+	return &Replayer{
+		Speed: 1,
+		dir:   dir,
+		pair:  pair,
+	}
+}
+
+// SubDepth replays the order book updates like Namebase.SubDepth.
+// The channel is closed at the end of the recording, after which Err
+// reports any error that stopped the replay
+func (r *Replayer) SubDepth() (chan Depth, error) {
+	rr, err := OpenRecording(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	chDepth := make(chan Depth, 1)
+
+	go func() {
+		defer close(chDepth)
+		defer rr.Close()
+
+		var book *depthBook
+		var last int64
+
+		for {
+			rec, err := rr.Next()
+			if err == io.EOF {
+				return
+			}
+
+			if err != nil {
+				r.err = err
+				return
+			}
+
+			if rec.Symbol != r.pair.String() {
+				continue
+			}
+
+			if rec.Stream != RecordDepth && rec.Stream != RecordDepthSnapshot {
+				continue
+			}
+
+			if last != 0 && r.Speed > 0 && rec.Time > last {
+				time.Sleep(time.Duration(float64(rec.Time-last) / r.Speed * float64(time.Millisecond)))
+			}
+			last = rec.Time
+
+			if rec.Stream == RecordDepthSnapshot {
+				if book != nil && !r.Resync {
+					continue
+				}
+
+				snapshot, err := parseDepth(rec.Data)
+				if err != nil {
+					r.err = err
+					return
+				}
+
+				if book == nil {
+					book = &depthBook{}
+				}
+				book.reset(snapshot)
+				continue
+			}
+
+			// diffs before the first snapshot cannot be applied
+			if book == nil {
+				continue
+			}
+
+			depth, ok, err := book.apply(rec.Data)
+			if errors.Is(err, ErrDepthGap) && r.Resync {
+				// wait for the next snapshot
+				book = nil
+				continue
+			}
+
+			if err != nil {
+				r.err = err
+				return
+			}
+
+			if ok {
+				chDepth <- depth
+			}
+		}
+	}()
+
+	return chDepth, nil
+}
+
+// Err returns the error which stopped the replay, if any
+func (r *Replayer) Err() error {
+	return r.err
+}
+
+// RecordedTrades reads the trades of pair from the recording in dir
+func RecordedTrades(dir string, pair CurrencyPair) ([]Trade, error) {
+	rr, err := OpenRecording(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+
+	var trades []Trade
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			return trades, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.Stream != RecordTrades || rec.Symbol != pair.String() {
+			continue
+		}
+
+		var t Trade
+		if err := json.Unmarshal(rec.Data, &t); err != nil {
+			return nil, err
+		}
+		trades = append(trades, t)
+	}
+}
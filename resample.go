@@ -0,0 +1,87 @@
+package namebase
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResampleKlines aggregates klines into klines of a larger interval, e.g. 1h klines
+// into 4h or monthly klines. klines must be sorted by open time and share the same
+// interval, which has to evenly divide to. Every resulting kline spans the whole
+// interval, from its aligned open time to one millisecond before the next one.
+func ResampleKlines(klines []Kline, to KlineInterval) ([]Kline, error) {
+	if len(klines) == 0 {
+		return nil, nil
+	}
+
+	if _, _, ok := to.parse(); !ok {
+		return nil, fmt.Errorf("invalid kline interval: %s", to)
+	}
+
+	from := time.Duration(klines[0].CloseTime-klines[0].OpenTime+1) * time.Millisecond
+	if from <= 0 {
+		return nil, fmt.Errorf("invalid kline, open time %d, close time %d",
+			klines[0].OpenTime, klines[0].CloseTime)
+	}
+
+	// months are made of whole days
+	unit := to.Duration()
+	if unit == 0 {
+		unit = 24 * time.Hour
+	}
+
+	if unit < from || unit%from != 0 {
+		return nil, fmt.Errorf("%s is not a multiple of %s", to, from)
+	}
+
+	var result []Kline
+	var bucket *Kline
+	var bucketEnd int64
+
+	for i, k := range klines {
+		if i > 0 && k.OpenTime <= klines[i-1].OpenTime {
+			return nil, fmt.Errorf("klines are not sorted by open time at %d", i)
+		}
+
+		if bucket != nil && k.OpenTime < bucketEnd {
+			mergeKline(bucket, k)
+			continue
+		}
+
+		if bucket != nil {
+			result = append(result, *bucket)
+		}
+
+		open := to.Align(msToTime(k.OpenTime))
+		bucketEnd = timeToMs(to.next(open))
+		bucket = &Kline{
+			OpenTime:       timeToMs(open),
+			CloseTime:      bucketEnd - 1,
+			OpenPrice:      k.OpenPrice,
+			HighPrice:      k.HighPrice,
+			LowPrice:       k.LowPrice,
+			ClosePrice:     k.ClosePrice,
+			Volume:         k.Volume,
+			QuoteVolume:    k.QuoteVolume,
+			NumberOfTrades: k.NumberOfTrades,
+		}
+	}
+
+	return append(result, *bucket), nil
+}
+
+// mergeKline merges k, which opens later than bucket, into bucket
+func mergeKline(bucket *Kline, k Kline) {
+	if k.HighPrice.GreaterThan(bucket.HighPrice) {
+		bucket.HighPrice = k.HighPrice
+	}
+
+	if k.LowPrice.LessThan(bucket.LowPrice) {
+		bucket.LowPrice = k.LowPrice
+	}
+
+	bucket.ClosePrice = k.ClosePrice
+	bucket.Volume = bucket.Volume.Add(k.Volume)
+	bucket.QuoteVolume = bucket.QuoteVolume.Add(k.QuoteVolume)
+	bucket.NumberOfTrades += k.NumberOfTrades
+}
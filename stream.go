@@ -0,0 +1,119 @@
+package namebase
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	depthStreamPath  = "/ws/v0/ticker/depth"
+	tradesStreamPath = "/ws/v0/stream/trades"
+)
+
+// stream dials the websocket at path for pair and passes every message to onMessage
+// in a new goroutine, reconnecting when reading fails. onConnect, if not nil,
+// is called after every successful dial before any message is read,
+// and the stream stops if it fails. The stream also stops when done is closed.
+// wg, if not nil, tracks the goroutines of the stream, so that waiting
+// for it after closing done guarantees onMessage is no longer called.
+func (nb *Namebase) stream(path string, pair CurrencyPair, done <-chan struct{},
+	wg *sync.WaitGroup, onConnect func() error, onMessage func([]byte)) error {
+	url := nb.wsURL + path
+	wsConn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		nb.log().Error("failed to establish a websocket connection",
+			"endpoint", path, "pair", pair, "error", err)
+		return err
+	}
+
+	if onConnect != nil {
+		if err := onConnect(); err != nil {
+			wsConn.Close()
+			return err
+		}
+	}
+
+	// a nil done is never closed
+	stopped := func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if wg != nil {
+		wg.Add(1)
+		if done != nil {
+			wg.Add(1)
+		}
+	}
+
+	var mu sync.Mutex
+	if done != nil {
+		go func() {
+			if wg != nil {
+				defer wg.Done()
+			}
+
+			<-done
+			mu.Lock()
+			wsConn.Close()
+			mu.Unlock()
+		}()
+	}
+
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+
+		for {
+			_, data, err := wsConn.ReadMessage()
+			if err != nil {
+				if stopped() {
+					return
+				}
+
+				nb.log().Warn("failed to read from websocket, reconnecting", "endpoint", path,
+					"pair", pair, "error", err, "localAddr", wsConn.LocalAddr())
+				wsConn.Close()
+				conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+				if err != nil {
+					nb.log().Error("failed to reconnect to websocket",
+						"endpoint", path, "pair", pair, "error", err)
+					// TODO notify subscriber about this error
+					return
+				}
+
+				mu.Lock()
+				wsConn = conn
+				mu.Unlock()
+				nb.observe().StreamReconnected(path)
+
+				if stopped() {
+					conn.Close()
+					return
+				}
+
+				if onConnect != nil {
+					if err := onConnect(); err != nil {
+						nb.log().Error("failed to resume websocket after reconnecting",
+							"endpoint", path, "pair", pair, "error", err)
+						return
+					}
+				}
+
+				continue
+			}
+
+			nb.tracer.frame(url, data)
+			nb.observe().StreamMessage(path)
+			onMessage(data)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package namebase
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of TraceEvent
+const (
+	TraceHTTP      = "http"
+	TraceWebsocket = "ws"
+)
+
+// TraceEvent is a REST request with its response, or a received websocket
+// frame, passed to the hook of WithTrace. The Authorization header and any
+// occurrence of the API key and secret are redacted
+type TraceEvent struct {
+	Kind   string
+	Time   time.Time
+	Method string
+	URL    string
+	Header http.Header
+	// Request is the body of the request
+	Request string
+	Status  int
+	Latency time.Duration
+	// Response is the body of the response, or the websocket frame
+	Response string
+	// Err is the error of requests without response
+	Err error
+}
+
+// WithTrace calls fn with every request and response of the client and every
+// frame it receives, fn must not block. Requests are traced after the rate
+// limiter, so Latency is the time the exchange took to answer
+func WithTrace(fn func(TraceEvent)) ClientOption {
+	return func(nb *Namebase) {
+		nb.tracer = &tracer{fn: fn}
+	}
+}
+
+// WithTraceWriter writes every request and response of the client, and
+// every frame it receives, to w in a readable form for debugging
+func WithTraceWriter(w io.Writer) ClientOption {
+	var mu sync.Mutex
+	return WithTrace(func(ev TraceEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		ts := ev.Time.UTC().Format("2006-01-02T15:04:05.000Z")
+		if ev.Kind == TraceWebsocket {
+			fmt.Fprintf(w, "%s WS %s\n< %s\n", ts, ev.URL, ev.Response)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s %s", ts, ev.Method, ev.URL)
+		if ev.Err != nil {
+			fmt.Fprintf(w, " error: %v %s\n", ev.Err, ev.Latency)
+		} else {
+			fmt.Fprintf(w, " %d %s\n", ev.Status, ev.Latency)
+		}
+		for k, v := range ev.Header {
+			fmt.Fprintf(w, "> %s: %s\n", k, strings.Join(v, ", "))
+		}
+		if ev.Request != "" {
+			fmt.Fprintf(w, "> %s\n", ev.Request)
+		}
+		if ev.Response != "" {
+			fmt.Fprintf(w, "< %s\n", strings.TrimRight(ev.Response, "\n"))
+		}
+	})
+}
+
+// tracer sends events to the hook of WithTrace, a nil tracer traces nothing
+type tracer struct {
+	fn func(TraceEvent)
+}
+
+// request traces a request, resp is nil when the request failed
+func (t *tracer) request(req *http.Request, body []byte, resp *http.Response, respBody []byte,
+	latency time.Duration, err error, creds Credentials) {
+	if t == nil {
+		return
+	}
+
+	header := http.Header{}
+	for k, v := range req.Header {
+		header[k] = v
+	}
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", "REDACTED")
+	}
+
+	ev := TraceEvent{
+		Kind:     TraceHTTP,
+		Time:     time.Now(),
+		Method:   req.Method,
+		URL:      redact(req.URL.String(), creds),
+		Header:   header,
+		Request:  redact(string(body), creds),
+		Latency:  latency,
+		Response: redact(string(respBody), creds),
+		Err:      err,
+	}
+	if resp != nil {
+		ev.Status = resp.StatusCode
+	}
+
+	t.fn(ev)
+}
+
+// frame traces a websocket frame received from url
+func (t *tracer) frame(url string, data []byte) {
+	if t == nil {
+		return
+	}
+
+	t.fn(TraceEvent{
+		Kind:     TraceWebsocket,
+		Time:     time.Now(),
+		URL:      url,
+		Response: string(data),
+	})
+}
+
+// redact replaces every occurrence of the key and secret in s
+func redact(s string, creds Credentials) string {
+	for _, secret := range []string{creds.Key, creds.Secret} {
+		if secret != "" {
+			s = strings.Replace(s, secret, "REDACTED", -1)
+		}
+	}
+
+	return s
+}
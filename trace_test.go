@@ -0,0 +1,94 @@
+package namebase
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTrace(t *testing.T) {
+	var mu sync.Mutex
+	var events []TraceEvent
+
+	s, client := newTestServer(t, WithCredentials(NewStaticCredentials("apikey-1234", "secret-5678")),
+		WithTrace(func(ev TraceEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}))
+	defer s.Close()
+	mu.Lock()
+	events = nil
+	mu.Unlock()
+
+	if _, err := client.LimitBuy(decimal.New(1, 0), decimal.RequireFromString("0.00001"),
+		NewCurrencyPair("hns", "btc")); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	ev := events[0]
+	mu.Unlock()
+	if ev.Kind != TraceHTTP || ev.Method != "POST" || ev.Status != 200 || ev.Latency <= 0 ||
+		!strings.Contains(ev.URL, "/api/v0/order") || !strings.Contains(ev.Request, `"side":"BUY"`) ||
+		!strings.Contains(ev.Response, `"orderId"`) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if ev.Header.Get("Authorization") != "REDACTED" {
+		t.Errorf("authorization is not redacted: %v", ev.Header)
+	}
+
+	if _, err := client.SubTrades(NewCurrencyPair("hns", "btc")); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, tradesStreamPath)
+	s.PushTrade(map[string]interface{}{"eventType": "trade", "tradeId": 9, "price": "1", "quantity": "1"})
+
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		last := events[len(events)-1]
+		mu.Unlock()
+		if last.Kind == TraceWebsocket {
+			if !strings.HasSuffix(last.URL, tradesStreamPath) || !strings.Contains(last.Response, `"tradeId":9`) {
+				t.Errorf("unexpected frame: %+v", last)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("frame is not traced")
+}
+
+func TestTraceWriter(t *testing.T) {
+	var buf bytes.Buffer
+	client, err := NewClient("apikey-1234", "secret-5678", WithBaseURL(srv.URL), WithTraceWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetAccount(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "GET "+srv.URL+"/api/v0/account?timestamp=") ||
+		!strings.Contains(out, "> Authorization: REDACTED") || !strings.Contains(out, `< {"balances"`) ||
+		strings.Contains(out, "Basic ") {
+		t.Errorf("unexpected trace output: %s", out)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	creds := Credentials{Key: "apikey-1234", Secret: "short"}
+	if got := redact("key=apikey-1234&s=short", creds); got != "key=REDACTED&s=REDACTED" {
+		t.Errorf("unexpected redaction: %s", got)
+	}
+
+	if got := redact("key=", Credentials{}); got != "key=" {
+		t.Errorf("empty credentials are redacted: %s", got)
+	}
+}
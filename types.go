@@ -2,7 +2,9 @@ package namebase
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -10,17 +12,106 @@ import (
 // KlineInterval is the interval of k-Line
 type KlineInterval string
 
+// Not every interval is served by the exchange, but all of them
+// can be built from smaller ones with ResampleKlines
 const (
 	// KlineInterval1Min
-	KlineInterval1Min  KlineInterval = "1m"
-	KlineInterval5Min  KlineInterval = "5m"
-	KlineInterval15Min KlineInterval = "15m"
-	KlineInterval1H    KlineInterval = "1h"
-	KlineInterval12H   KlineInterval = "12h"
-	KlineInterval1Day  KlineInterval = "1d"
-	KlineInterval1Week               = "1w"
+	KlineInterval1Min   KlineInterval = "1m"
+	KlineInterval5Min   KlineInterval = "5m"
+	KlineInterval15Min  KlineInterval = "15m"
+	KlineInterval1H     KlineInterval = "1h"
+	KlineInterval4H     KlineInterval = "4h"
+	KlineInterval6H     KlineInterval = "6h"
+	KlineInterval12H    KlineInterval = "12h"
+	KlineInterval1Day   KlineInterval = "1d"
+	KlineInterval1Week  KlineInterval = "1w"
+	KlineInterval1Month KlineInterval = "1M"
 )
 
+// weekEpoch is the first Monday after the unix epoch, weekly klines open on Mondays
+var weekEpoch = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// parse splits the interval into count and unit, e.g. "15m" into 15 and 'm'
+func (i KlineInterval) parse() (int, byte, bool) {
+	s := string(i)
+	if len(s) < 2 {
+		return 0, 0, false
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, 0, false
+	}
+
+	unit := s[len(s)-1]
+	switch unit {
+	case 'm', 'h', 'd', 'w', 'M':
+		return n, unit, true
+	}
+
+	return 0, 0, false
+}
+
+// Duration returns the length of the interval. It is zero for
+// monthly intervals, whose length varies, and for invalid intervals
+func (i KlineInterval) Duration() time.Duration {
+	n, unit, ok := i.parse()
+	if !ok {
+		return 0
+	}
+
+	switch unit {
+	case 'm':
+		return time.Duration(n) * time.Minute
+	case 'h':
+		return time.Duration(n) * time.Hour
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour
+	}
+
+	return 0
+}
+
+// Align returns the open time of the interval which t falls in, in UTC.
+// Weeks open on Mondays and months on the first day of the month
+func (i KlineInterval) Align(t time.Time) time.Time {
+	t = t.UTC()
+	n, unit, ok := i.parse()
+	if !ok {
+		return t
+	}
+
+	if unit == 'M' {
+		months := (t.Year()-1970)*12 + int(t.Month()) - 1
+		months -= ((months % n) + n) % n
+		return time.Date(1970, time.Month(months+1), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	epoch := time.Unix(0, 0).UTC()
+	if unit == 'w' {
+		epoch = weekEpoch
+	}
+
+	d := i.Duration()
+	offset := t.Sub(epoch) % d
+	if offset < 0 {
+		offset += d
+	}
+
+	return t.Add(-offset)
+}
+
+// next returns the open time of the interval following the one opened at t
+func (i KlineInterval) next(t time.Time) time.Time {
+	if n, unit, ok := i.parse(); ok && unit == 'M' {
+		return t.AddDate(0, n, 0)
+	}
+
+	return t.Add(i.Duration())
+}
+
 // OrderSide is either BUY or SELL
 type OrderSide string
 
@@ -29,6 +120,61 @@ const (
 	SellOrder OrderSide = "SELL"
 )
 
+func (s OrderSide) known() bool {
+	return s == BuyOrder || s == SellOrder
+}
+
+// OrderType is either LMT or MKT
+type OrderType string
+
+const (
+	OrderTypeLimit  OrderType = "LMT"
+	OrderTypeMarket OrderType = "MKT"
+)
+
+func (t OrderType) known() bool {
+	return t == OrderTypeLimit || t == OrderTypeMarket
+}
+
+// OrderStatus is the status of an order
+type OrderStatus string
+
+const (
+	OrderStatusNew             OrderStatus = "NEW"
+	OrderStatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	OrderStatusFilled          OrderStatus = "FILLED"
+	OrderStatusCanceled        OrderStatus = "CANCELED"
+	OrderStatusPendingCancel   OrderStatus = "PENDING_CANCEL"
+	OrderStatusRejected        OrderStatus = "REJECTED"
+	OrderStatusExpired         OrderStatus = "EXPIRED"
+)
+
+// IsOpen reports whether the order may still be executed
+func (s OrderStatus) IsOpen() bool {
+	return s == OrderStatusNew || s == OrderStatusPartiallyFilled ||
+		s == OrderStatusPendingCancel
+}
+
+// IsTerminal reports whether the order will not change anymore
+func (s OrderStatus) IsTerminal() bool {
+	return s == OrderStatusFilled || s == OrderStatusCanceled ||
+		s == OrderStatusRejected || s == OrderStatusExpired
+}
+
+func (s OrderStatus) known() bool {
+	return s.IsOpen() || s.IsTerminal()
+}
+
+// OrderRequest describes an order to be placed
+type OrderRequest struct {
+	Pair     CurrencyPair
+	Side     OrderSide
+	Type     OrderType
+	Quantity decimal.Decimal
+	// Price is ignored by market orders
+	Price decimal.Decimal
+}
+
 // Currency is the symbol of crypto currency, such as BTC, ETH, etc.
 type Currency string
 
@@ -112,38 +258,128 @@ type Depth struct {
 
 // Kline is candlestick
 type Kline struct {
-	OpenTime       int64  `json:"openTime"`
-	CloseTime      int64  `json:"closeTime"`
-	OpenPrice      string `json:"openPrice"`
-	HighPrice      string `json:"highPrice"`
-	LowPrice       string `json:"lowPrice"`
-	ClosePrice     string `json:"closePrice"`
-	Volume         string `json:"volume"`
-	QuoteVolume    string `json:"quoteVolume"`
-	NumberOfTrades int    `json:"numberOfTrades"`
+	OpenTime       int64           `json:"openTime"`
+	CloseTime      int64           `json:"closeTime"`
+	OpenPrice      decimal.Decimal `json:"openPrice"`
+	HighPrice      decimal.Decimal `json:"highPrice"`
+	LowPrice       decimal.Decimal `json:"lowPrice"`
+	ClosePrice     decimal.Decimal `json:"closePrice"`
+	Volume         decimal.Decimal `json:"volume"`
+	QuoteVolume    decimal.Decimal `json:"quoteVolume"`
+	NumberOfTrades int             `json:"numberOfTrades"`
 }
 
+// OpenAt returns OpenTime as time.Time
+func (k Kline) OpenAt() time.Time {
+	return msToTime(k.OpenTime)
+}
+
+// CloseAt returns CloseTime as time.Time
+func (k Kline) CloseAt() time.Time {
+	return msToTime(k.CloseTime)
+}
+
+// Trade is an executed trade of a pair
 type Trade struct {
-	TradeID       int    `json:"tradeId"`
-	Price         string `json:"price"`
-	Quantity      string `json:"quantity"`
-	QuoteQuantity string `json:"quoteQuantity"`
-	CreatedAt     int64  `json:"createdAt"`
-	IsBuyerMaker  bool   `json:"isBuyerMaker"`
+	TradeID       int             `json:"tradeId"`
+	Price         decimal.Decimal `json:"price"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	QuoteQuantity decimal.Decimal `json:"quoteQuantity"`
+	CreatedAt     int64           `json:"createdAt"`
+	IsBuyerMaker  bool            `json:"isBuyerMaker"`
+}
+
+// CreatedTime returns CreatedAt as time.Time
+func (t Trade) CreatedTime() time.Time {
+	return msToTime(t.CreatedAt)
+}
+
+// msToTime converts milliseconds since epoch, which the API uses for timestamps
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// timeToMs converts t to milliseconds since epoch
+func timeToMs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Balance is the balance of an asset
+type Balance struct {
+	Asset       Currency        `json:"asset"`
+	Unlocked    decimal.Decimal `json:"unlocked"`
+	Locked      decimal.Decimal `json:"lockedInOrders"`
+	CanDeposit  bool            `json:"canDeposit"`
+	CanWithdraw bool            `json:"canWithdraw"`
+}
+
+// Total is the sum of unlocked and locked amount
+func (b Balance) Total() decimal.Decimal {
+	return b.Unlocked.Add(b.Locked)
 }
 
 // Account represents account info
 type Account struct {
-	MakerFee int  `json:"makerFee"`
-	TakerFee int  `json:"takerFee"`
-	CanTrade bool `json:"canTrade"`
-	Balances []struct {
-		Asset          string `json:"asset"`
-		Unlocked       string `json:"unlocked"`
-		LockedInOrders string `json:"lockedInOrders"`
-		CanDeposit     bool   `json:"canDeposit"`
-		CanWithdraw    bool   `json:"canWithdraw"`
-	} `json:"balances"`
+	MakerFee int       `json:"makerFee"`
+	TakerFee int       `json:"takerFee"`
+	CanTrade bool      `json:"canTrade"`
+	Balances []Balance `json:"balances"`
+}
+
+// Balance looks up the balance of an asset
+func (a *Account) Balance(asset Currency) (Balance, bool) {
+	asset = NewCurrency(string(asset))
+	for _, b := range a.Balances {
+		if NewCurrency(string(b.Asset)) == asset {
+			return b, true
+		}
+	}
+
+	return Balance{Asset: asset}, false
+}
+
+// ValueIn sums the total of all balances valued in quote currency.
+// prices holds the price of each pair either quoted in quote currency,
+// or with quote currency as the base, in which case it is inverted
+func (a *Account) ValueIn(quote Currency, prices map[CurrencyPair]decimal.Decimal) (decimal.Decimal, error) {
+	quote = NewCurrency(string(quote))
+	value := decimal.Zero
+
+	for _, b := range a.Balances {
+		total := b.Total()
+		asset := NewCurrency(string(b.Asset))
+		if total.IsZero() {
+			continue
+		}
+
+		if asset == quote {
+			value = value.Add(total)
+			continue
+		}
+
+		if p, ok := prices[CurrencyPair{asset, quote}]; ok {
+			value = value.Add(total.Mul(p))
+			continue
+		}
+
+		if p, ok := prices[CurrencyPair{quote, asset}]; ok && !p.IsZero() {
+			value = value.Add(total.Div(p))
+			continue
+		}
+
+		return decimal.Zero, fmt.Errorf("no price to value %s in %s", asset, quote)
+	}
+
+	return value, nil
+}
+
+// Fill is a partial execution of an order
+type Fill struct {
+	Price           decimal.Decimal `json:"price"`
+	Quantity        decimal.Decimal `json:"quantity"`
+	QuoteQuantity   decimal.Decimal `json:"quoteQuantity"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
 }
 
 // Order is order
@@ -152,11 +388,123 @@ type Order struct {
 	Price,
 	OriginalQuantity,
 	ExecutedQuantity decimal.Decimal
-	Status    string `json:"status"`
-	Type      string `json:"type"`
-	Side      string `json:"side"`
-	CreatedAt int64  `json:"createdAt"`
-	UpdatedAt int64  `json:"updatedAt"`
+	Status    OrderStatus `json:"status"`
+	Type      OrderType   `json:"type"`
+	Side      OrderSide   `json:"side"`
+	CreatedAt int64       `json:"createdAt"`
+	UpdatedAt int64       `json:"updatedAt"`
+	// Fills is only returned when the order is placed
+	Fills []Fill `json:"fills"`
+}
+
+// checkEnums returns an error if the status, type or side of the order
+// is not one of the known values
+func (o *Order) checkEnums() error {
+	switch {
+	case !o.Status.known():
+		return fmt.Errorf("unknown order status: %q", o.Status)
+	case !o.Type.known():
+		return fmt.Errorf("unknown order type: %q", o.Type)
+	case !o.Side.known():
+		return fmt.Errorf("unknown order side: %q", o.Side)
+	}
+
+	return nil
+}
+
+// IsOpen reports whether the order may still be executed
+func (o *Order) IsOpen() bool {
+	return o.Status.IsOpen()
+}
+
+// IsTerminal reports whether the order will not change anymore
+func (o *Order) IsTerminal() bool {
+	return o.Status.IsTerminal()
+}
+
+// RemainingQuantity is the quantity not executed yet
+func (o *Order) RemainingQuantity() decimal.Decimal {
+	remaining := o.OriginalQuantity.Sub(o.ExecutedQuantity)
+	if remaining.IsNegative() {
+		return decimal.Zero
+	}
+
+	return remaining
+}
+
+// FillRatio is the executed part of the order, from 0 to 1
+func (o *Order) FillRatio() decimal.Decimal {
+	if o.OriginalQuantity.IsZero() {
+		return decimal.Zero
+	}
+
+	return o.ExecutedQuantity.Div(o.OriginalQuantity)
+}
+
+// AvgPrice is the average execution price, calculated from the fills if any,
+// otherwise limit orders are assumed to be executed at their price.
+// It is zero if nothing has been executed
+func (o *Order) AvgPrice() decimal.Decimal {
+	qty, quoteQty := decimal.Zero, decimal.Zero
+	for _, f := range o.Fills {
+		qty = qty.Add(f.Quantity)
+		quoteQty = quoteQty.Add(f.QuoteQuantity)
+	}
+
+	if !qty.IsZero() {
+		return quoteQty.Div(qty)
+	}
+
+	if o.ExecutedQuantity.IsZero() || o.Type != OrderTypeLimit {
+		return decimal.Zero
+	}
+
+	return o.Price
+}
+
+// Withdrawal is a withdrawal of funds from the exchange,
+// Fee is the miner fee, paid in Asset
+type Withdrawal struct {
+	ID        string          `json:"id"`
+	Asset     Currency        `json:"asset"`
+	Amount    decimal.Decimal `json:"amount"`
+	Fee       decimal.Decimal `json:"minerFee"`
+	Address   string          `json:"address"`
+	Memo      string          `json:"memo"`
+	TxHash    string          `json:"txHash"`
+	Status    string          `json:"status"`
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// CreatedTime returns CreatedAt as time.Time
+func (w Withdrawal) CreatedTime() time.Time {
+	return msToTime(w.CreatedAt)
+}
+
+// DepositAddress is where to deposit an asset, Memo must be
+// sent with the deposit when it is not empty
+type DepositAddress struct {
+	Asset   Currency `json:"asset"`
+	Address string   `json:"address"`
+	Memo    string   `json:"memo"`
+	Network string   `json:"network"`
+	Success bool     `json:"success"`
+}
+
+// Deposit is a deposit of funds to the exchange
+type Deposit struct {
+	ID        string          `json:"id"`
+	Asset     Currency        `json:"asset"`
+	Amount    decimal.Decimal `json:"amount"`
+	Address   string          `json:"address"`
+	TxHash    string          `json:"txHash"`
+	Status    string          `json:"status"`
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// CreatedTime returns CreatedAt as time.Time
+func (d Deposit) CreatedTime() time.Time {
+	return msToTime(d.CreatedAt)
 }
 
 type symbolInfo struct {
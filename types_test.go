@@ -0,0 +1,107 @@
+package namebase
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+func TestOrderHelpers(t *testing.T) {
+	raw := `{"orderId":174,"price":"0.00001","originalQuantity":"4","executedQuantity":"1",
+		"status":"PARTIALLY_FILLED","type":"LMT","side":"BUY",
+		"fills":[{"price":"0.000009","quantity":"1","quoteQuantity":"0.000009"}]}`
+
+	var o Order
+	if err := json.Unmarshal([]byte(raw), &o); err != nil {
+		t.Fatal(err)
+	}
+
+	if !o.IsOpen() || o.IsTerminal() {
+		t.Errorf("%s should be open", o.Status)
+	}
+
+	if s := o.RemainingQuantity().String(); s != "3" {
+		t.Errorf("remaining quantity: %s", s)
+	}
+
+	if s := o.FillRatio().String(); s != "0.25" {
+		t.Errorf("fill ratio: %s", s)
+	}
+
+	if s := o.AvgPrice().String(); s != "0.000009" {
+		t.Errorf("avg price: %s", s)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "UNKNOWN",
+		Type: "LMT", Side: "BUY", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "0"})
+	pair := NewCurrencyPair("hns", "btc")
+
+	if o, err := nb.GetOrder(id, pair); err != nil || o.Status != "UNKNOWN" {
+		t.Errorf("lenient decoding: %v, order: %+v", err, o)
+	}
+
+	client, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithStrictDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetOrder(id, pair); err == nil {
+		t.Error("expected error on unknown status")
+	}
+
+	if _, err := client.OpenOrders(pair); err != nil {
+		t.Errorf("known orders are rejected: %v", err)
+	}
+}
+
+func TestKlineDecoding(t *testing.T) {
+	raw := `{"openTime":1557057600000,"closeTime":1557061199999,"openPrice":"0.00000800",
+		"highPrice":"0.00000900","lowPrice":"0.00000700","closePrice":"0.00000850",
+		"volume":"1000.5","quoteVolume":"0.008","numberOfTrades":3}`
+
+	var k Kline
+	if err := json.Unmarshal([]byte(raw), &k); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := k.HighPrice.String(); s != "0.000009" {
+		t.Errorf("high price: %s", s)
+	}
+
+	if ts := k.OpenAt().UnixNano(); ts != 1557057600000*1e6 {
+		t.Errorf("open time: %d", ts)
+	}
+}
+
+func TestAccountBalances(t *testing.T) {
+	raw := `{"makerFee":10,"takerFee":10,"canTrade":true,"balances":[
+		{"asset":"HNS","unlocked":"100","lockedInOrders":"50"},
+		{"asset":"BTC","unlocked":"0.5","lockedInOrders":"0"},
+		{"asset":"ETH","unlocked":"0","lockedInOrders":"0"}]}`
+
+	var acct Account
+	if err := json.Unmarshal([]byte(raw), &acct); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, ok := acct.Balance(NewCurrency("hns")); !ok || b.Total().String() != "150" {
+		t.Errorf("hns balance: %+v, found: %v", b, ok)
+	}
+
+	prices := map[CurrencyPair]decimal.Decimal{
+		NewCurrencyPair("hns", "btc"): decimal.NewFromFloat(0.00001),
+	}
+
+	if v, err := acct.ValueIn("BTC", prices); err != nil || v.String() != "0.5015" {
+		t.Errorf("value in btc: %s, err: %v", v, err)
+	}
+
+	if v, err := acct.ValueIn("HNS", prices); err != nil || v.String() != "50150" {
+		t.Errorf("value in hns: %s, err: %v", v, err)
+	}
+}
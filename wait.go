@@ -0,0 +1,119 @@
+package namebase
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultWaitPollInterval    = 500 * time.Millisecond
+	defaultWaitMaxPollInterval = 10 * time.Second
+)
+
+// ErrWaitTimeout is returned by WaitOrder along with the final order
+// when the order has been canceled because of WaitOptions.CancelAfter
+var ErrWaitTimeout = errors.New("order canceled after wait timeout")
+
+// WaitOptions customizes WaitOrder
+type WaitOptions struct {
+	// Updates delivers order updates, e.g. from a user data stream.
+	// Polling goes on as a fallback while it is set
+	Updates <-chan Order
+	// PollInterval is the first interval between queries of the order,
+	// it doubles after each query up to MaxPollInterval
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// CancelAfter cancels the order if it is still open after the duration
+	CancelAfter time.Duration
+}
+
+// WaitOrder blocks until the order is filled, canceled, rejected or expired
+// and returns its final state. If the order is canceled because of
+// opts.CancelAfter, the order is returned with ErrWaitTimeout, and its
+// ExecutedQuantity tells how much has been filled. opts may be nil.
+func (nb *Namebase) WaitOrder(ctx context.Context, orderID int, pair CurrencyPair,
+	opts *WaitOptions) (*Order, error) {
+	o := WaitOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultWaitPollInterval
+	}
+
+	if o.MaxPollInterval < o.PollInterval {
+		o.MaxPollInterval = defaultWaitMaxPollInterval
+		if o.MaxPollInterval < o.PollInterval {
+			o.MaxPollInterval = o.PollInterval
+		}
+	}
+
+	var timeout <-chan time.Time
+	if o.CancelAfter > 0 {
+		timer := time.NewTimer(o.CancelAfter)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var last *Order
+	var lastErr error
+	interval := o.PollInterval
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return last, lastErr
+			}
+			return last, ctx.Err()
+
+		case <-timeout:
+			final, err := nb.cancelAndConfirm(ctx, orderID, pair)
+			if err != nil {
+				return final, err
+			}
+
+			if final.Status == OrderStatusFilled {
+				return final, nil
+			}
+			return final, ErrWaitTimeout
+
+		case u, ok := <-o.Updates:
+			if !ok {
+				// a nil channel blocks forever, so only polling is left
+				o.Updates = nil
+				continue
+			}
+
+			if u.OrderID != orderID {
+				continue
+			}
+
+			last = &u
+			if u.IsTerminal() {
+				return last, nil
+			}
+
+		case <-poll.C:
+			order, err := nb.GetOrder(orderID, pair)
+			if err == nil {
+				last, lastErr = order, nil
+				if order.IsTerminal() {
+					return order, nil
+				}
+			} else {
+				lastErr = err
+			}
+
+			poll.Reset(interval)
+			interval *= 2
+			if interval > o.MaxPollInterval {
+				interval = o.MaxPollInterval
+			}
+		}
+	}
+}
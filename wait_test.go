@@ -0,0 +1,66 @@
+package namebase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+func TestWaitOrderCancelAfter(t *testing.T) {
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "NEW",
+		Type: "LMT", Side: "SELL", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "0"})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		srv.UpdateOrder(id, func(o *namebasetest.Order) {
+			o.Status, o.ExecutedQuantity = "PARTIALLY_FILLED", "25"
+		})
+	}()
+
+	o, err := nb.WaitOrder(context.Background(), id, NewCurrencyPair("hns", "btc"), &WaitOptions{
+		PollInterval: 10 * time.Millisecond,
+		CancelAfter:  100 * time.Millisecond,
+	})
+	if err != ErrWaitTimeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	if o.Status != OrderStatusCanceled || o.ExecutedQuantity.String() != "25" {
+		t.Errorf("unexpected order: %+v", o)
+	}
+}
+
+func TestWaitOrderCancelAfterContext(t *testing.T) {
+	id := srv.AddOrder(namebasetest.Order{Symbol: "HNSBTC", Status: "NEW",
+		Type: "LMT", Side: "SELL", Price: "0.00001", OriginalQuantity: "100", ExecutedQuantity: "0"})
+	// the cancel is accepted but the order stays open
+	srv.InjectError("DELETE", "/api/v0/order", 200, "{}")
+
+	// requests of other tests must not hold it up in the rate limiter
+	client, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithRateLimit(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	o, err := client.WaitOrder(ctx, id, NewCurrencyPair("hns", "btc"), &WaitOptions{
+		PollInterval: time.Second,
+		CancelAfter:  10 * time.Millisecond,
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= cancelConfirmInterval {
+		t.Errorf("confirming the cancel ignores the context: %s", elapsed)
+	}
+
+	if o == nil || o.Status != OrderStatusNew {
+		t.Errorf("unexpected order: %+v", o)
+	}
+}
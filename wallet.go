@@ -0,0 +1,184 @@
+package namebase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// historyPageLimit is the max number of records returned by one history request
+const historyPageLimit = 100
+
+// depositAddrs caches the current deposit address of each asset,
+// a nil cache keeps nothing
+type depositAddrs struct {
+	mu    sync.Mutex
+	addrs map[Currency]DepositAddress
+	// misses serializes the generation of the address of each asset
+	// on a cache miss, so that concurrent calls generate only one
+	misses map[Currency]*sync.Mutex
+}
+
+// lock locks the cache misses of asset and returns the unlock function
+func (c *depositAddrs) lock(asset Currency) func() {
+	if c == nil {
+		return func() {}
+	}
+
+	asset = NewCurrency(string(asset))
+	c.mu.Lock()
+	if c.misses == nil {
+		c.misses = make(map[Currency]*sync.Mutex)
+	}
+	m, ok := c.misses[asset]
+	if !ok {
+		m = &sync.Mutex{}
+		c.misses[asset] = m
+	}
+	c.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+func (c *depositAddrs) get(asset Currency) (*DepositAddress, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addr, ok := c.addrs[NewCurrency(string(asset))]
+	return &addr, ok
+}
+
+func (c *depositAddrs) set(asset Currency, addr *DepositAddress) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addrs[NewCurrency(string(asset))] = *addr
+}
+
+// GetWithdrawal looks up a withdrawal by the ID returned by Withdraw.
+// The exchange has no endpoint for a single withdrawal, so it is searched
+// in the whole withdrawal history
+func (nb *Namebase) GetWithdrawal(id string) (*Withdrawal, error) {
+	history, err := nb.WithdrawalHistory("", time.Unix(0, 0), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range history {
+		if history[i].ID == id {
+			return &history[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("withdrawal %s not found", id)
+}
+
+// WithdrawalHistory returns the withdrawals of asset made between from and to,
+// oldest first, paging through the API as many times as needed.
+// An empty asset returns the withdrawals of every asset
+func (nb *Namebase) WithdrawalHistory(asset Currency, from, to time.Time) ([]Withdrawal, error) {
+	var result []Withdrawal
+	seen := make(map[string]bool)
+	err := nb.history("/api/v0/withdraw/history", asset, from, to, func(data []byte) (int, int, error) {
+		var page []Withdrawal
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, 0, err
+		}
+
+		added := 0
+		for _, w := range page {
+			if !seen[w.ID] {
+				seen[w.ID] = true
+				result = append(result, w)
+				added++
+			}
+		}
+		return len(page), added, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DepositHistory returns the deposits of asset made between from and to,
+// oldest first, paging through the API as many times as needed.
+// An empty asset returns the deposits of every asset
+func (nb *Namebase) DepositHistory(asset Currency, from, to time.Time) ([]Deposit, error) {
+	var result []Deposit
+	seen := make(map[string]bool)
+	err := nb.history("/api/v0/deposit/history", asset, from, to, func(data []byte) (int, int, error) {
+		var page []Deposit
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, 0, err
+		}
+
+		added := 0
+		for _, d := range page {
+			if !seen[d.ID] {
+				seen[d.ID] = true
+				result = append(result, d)
+				added++
+			}
+		}
+		return len(page), added, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// history requests the pages of a history endpoint between from and to.
+// page decodes a page and returns its number of records and how many of
+// them are new, deduplicated by ID. The next page starts at the offset of
+// the records read so far, so that records created in the same millisecond
+// are never skipped, and paging stops when a page adds no new record,
+// in case the exchange ignores the offset
+func (nb *Namebase) history(endpoint string, asset Currency, from, to time.Time,
+	page func(data []byte) (int, int, error)) error {
+	if to.Before(from) {
+		return fmt.Errorf("end %s is before start %s", to, from)
+	}
+
+	offset := 0
+	for {
+		params := make(map[string]interface{})
+		if asset != "" {
+			params["asset"] = string(NewCurrency(string(asset)))
+		}
+		params["startTime"] = timeToMs(from)
+		params["endTime"] = timeToMs(to)
+		params["offset"] = offset
+		params["limit"] = historyPageLimit
+
+		data, err := nb.do(http.MethodGet, endpoint, params, true)
+		if err != nil {
+			return err
+		}
+
+		n, added, err := page(data)
+		if err != nil {
+			return err
+		}
+
+		// no progress means the exchange returns the same page again
+		if n < historyPageLimit || added == 0 {
+			return nil
+		}
+		offset += n
+	}
+}
@@ -0,0 +1,196 @@
+package namebase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sniperem/namebase/namebasetest"
+)
+
+func TestDepositHistoryPages(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+
+	start := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 250; i++ {
+		s.AddDeposit(namebasetest.Deposit{
+			Asset:  "HNS",
+			Amount: "1",
+			// two deposits per millisecond, across page boundaries
+			CreatedAt: timeToMs(start) + int64(i/2),
+		})
+	}
+
+	deposits, err := client.DepositHistory("hns", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deposits) != 250 {
+		t.Fatalf("%d deposits", len(deposits))
+	}
+
+	for i := 1; i < len(deposits); i++ {
+		if deposits[i].CreatedAt < deposits[i-1].CreatedAt || deposits[i].ID == deposits[i-1].ID {
+			t.Fatalf("unsorted deposits at %d: %+v", i, deposits[i-1:i+1])
+		}
+	}
+
+	if _, err := client.DepositHistory("HNS", start, start.Add(-time.Second)); err == nil {
+		t.Error("reversed range is accepted")
+	}
+}
+
+func TestDepositHistorySameMillisecond(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+
+	start := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	n := historyPageLimit*2 + 10
+	for i := 0; i < n; i++ {
+		s.AddDeposit(namebasetest.Deposit{Asset: "HNS", Amount: "1", CreatedAt: timeToMs(start)})
+	}
+
+	deposits, err := client.DepositHistory("HNS", start, start.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deposits) != n {
+		t.Fatalf("expected %d deposits, got %d", n, len(deposits))
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range deposits {
+		if seen[d.ID] {
+			t.Fatalf("deposit %s is returned twice", d.ID)
+		}
+		seen[d.ID] = true
+	}
+}
+
+func TestDepositHistoryNoProgress(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+
+	// an exchange ignoring the offset returns the first page again and again
+	page := make([]namebasetest.Deposit, historyPageLimit)
+	for i := range page {
+		page[i] = namebasetest.Deposit{ID: fmt.Sprintf("d%d", i), Asset: "HNS", Amount: "1"}
+	}
+	s.Handle("GET", "/api/v0/deposit/history", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(page)
+	})
+
+	done := make(chan struct{})
+	var deposits []Deposit
+	var err error
+	go func() {
+		defer close(done)
+		deposits, err = client.DepositHistory("HNS", time.Unix(0, 0), time.Unix(10, 0))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("paging does not stop")
+	}
+
+	if err != nil || len(deposits) != historyPageLimit {
+		t.Errorf("expected %d deposits, got %d, %v", historyPageLimit, len(deposits), err)
+	}
+}
+
+func TestGetWithdrawal(t *testing.T) {
+	s, client := newTestServer(t)
+	defer s.Close()
+
+	s.AddWithdrawal(namebasetest.Withdrawal{Asset: "BTC", Amount: "1", MinerFee: "0", CreatedAt: 1000})
+	id := s.AddWithdrawal(namebasetest.Withdrawal{Asset: "HNS", Amount: "100", MinerFee: "0.2", CreatedAt: 2000})
+
+	if w, err := client.GetWithdrawal(id); err != nil {
+		t.Error(err)
+	} else if w.ID != id || w.Amount.String() != "100" {
+		t.Errorf("unexpected withdrawal: %+v", w)
+	}
+
+	if _, err := client.GetWithdrawal("missing"); err == nil {
+		t.Error("missing withdrawal is found")
+	}
+}
+
+func TestDepositAddr(t *testing.T) {
+	client, err := NewClient("key", "secret", WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := "hs1qz5tpwxqergd3c8g7ruszzg3rysjjvfegcdu6yc"
+	defer srv.SetDepositAddress(testAddress)
+	defer srv.SetDepositMemo("")
+
+	srv.SetDepositMemo("1234")
+	first, err := client.DepositAddr("HNS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Address != testAddress || first.Memo != "1234" {
+		t.Errorf("unexpected address: %+v", first)
+	}
+
+	// the cached address is reused
+	srv.SetDepositAddress(other)
+	if addr, err := client.DepositAddr("hns"); err != nil || addr.Address != testAddress {
+		t.Errorf("unexpected address: %+v, %v", addr, err)
+	}
+
+	if addr, err := client.NewDepositAddr("HNS"); err != nil || addr.Address != other {
+		t.Errorf("unexpected new address: %+v, %v", addr, err)
+	}
+	if addr, _ := client.DepositAddr("HNS"); addr.Address != other {
+		t.Errorf("new address is not current: %+v", addr)
+	}
+
+	srv.SetDepositAddress("")
+	if _, err := client.NewDepositAddr("BTC"); err == nil {
+		t.Error("unsuccessful response is accepted")
+	}
+}
+
+func TestDepositAddrConcurrent(t *testing.T) {
+	client, err := NewClient("key", "secret", WithBaseURL(srv.URL), WithRateLimit(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Path == "/api/v0/deposit/address" {
+				n++
+			}
+		}
+		return n
+	}
+	before := count()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.DepositAddr("HNS"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := count() - before; n != 1 {
+		t.Errorf("expected 1 generated address, got %d", n)
+	}
+}